@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/peterbourgon/ff/v3"
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/peterbourgon/ff/v3/fftoml"
+	"gopkg.in/yaml.v2"
+)
+
+const EnvVarPrefix = "JRNLSYNC"
+
+var ErrUnsupportedConfigFormat = errors.New("config files have to be .toml, .yaml, .yml or .json: ")
+var ErrFailedToReadConfig = errors.New("failed to read the config file: ")
+var ErrFailedToWriteConfig = errors.New("failed to write the config file: ")
+
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml", "config.json"}
+
+func DefaultPath() string {
+    configHome := os.Getenv("XDG_CONFIG_HOME")
+    if configHome == "" {
+        home, err := os.UserHomeDir()
+        if err == nil {
+            configHome = filepath.Join(home, ".config")
+        }
+    }
+    dir := filepath.Join(configHome, "jrnlSync")
+    for _, name := range configFileNames {
+        path := filepath.Join(dir, name)
+        if _, err := os.Stat(path); err == nil {
+            return path
+        }
+    }
+    return filepath.Join(dir, configFileNames[0])
+}
+
+// Apply lets every subcommand of root read its flags from JRNLSYNC_* env vars
+// and the config file at *path, in that order after the command line.
+func Apply(root *ffcli.Command, path *string) {
+    root.Options = []ff.Option{ff.WithEnvVarPrefix(EnvVarPrefix)}
+    for _, command := range root.Subcommands {
+        applyToCommand(command, path)
+    }
+}
+
+func applyToCommand(command *ffcli.Command, path *string) {
+    command.Options = Options(command.Name, path)
+    for _, subcommand := range command.Subcommands {
+        applyToCommand(subcommand, path)
+    }
+}
+
+func Options(command string, path *string) []ff.Option {
+    return []ff.Option{
+        ff.WithEnvVarPrefix(EnvVarPrefix),
+        ff.WithConfigFileVia(path),
+        ff.WithConfigFileParser(sectionParser(command, path)),
+        ff.WithAllowMissingConfigFile(true),
+        ff.WithIgnoreUndefined(true),
+    }
+}
+
+// sectionParser reads top level keys as flags for every command and keys in a
+// table named after the command as flags for just that command, which win over
+// the top level ones.
+func sectionParser(command string, path *string) ff.ConfigFileParser {
+    return func(r io.Reader, set func(name, value string) error) error {
+        global := make(map[string][]string)
+        section := make(map[string][]string)
+        err := parse(*path, r, func(name, value string) error {
+            switch {
+            case strings.HasPrefix(name, command+"."):
+                name = strings.TrimPrefix(name, command+".")
+                section[name] = append(section[name], value)
+            case !strings.Contains(name, "."):
+                global[name] = append(global[name], value)
+            }
+            return nil
+        })
+        if err != nil {
+            return err
+        }
+        for name, values := range section {
+            global[name] = values
+        }
+        names := make([]string, 0, len(global))
+        for name := range global {
+            names = append(names, name)
+        }
+        sort.Strings(names)
+        for _, name := range names {
+            for _, value := range global[name] {
+                err := set(name, value)
+                if err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    }
+}
+
+func parse(path string, r io.Reader, set func(name, value string) error) error {
+    contents, err := io.ReadAll(r)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+    }
+    var tree interface{}
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".toml":
+        err = fftoml.New(fftoml.WithTableDelimiter(".")).Parse(bytes.NewReader(contents), set)
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+        }
+        return nil
+    case ".yaml", ".yml":
+        err = yaml.Unmarshal(contents, &tree)
+    case ".json":
+        decoder := json.NewDecoder(bytes.NewReader(contents))
+        decoder.UseNumber()
+        err = decoder.Decode(&tree)
+    default:
+        return fmt.Errorf("%w%s", ErrUnsupportedConfigFormat, path)
+    }
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+    }
+    return flatten("", tree, set)
+}
+
+func flatten(prefix string, value interface{}, set func(name, value string) error) error {
+    join := func(key interface{}) string {
+        if prefix == "" {
+            return fmt.Sprint(key)
+        }
+        return fmt.Sprintf("%s.%v", prefix, key)
+    }
+    switch v := value.(type) {
+    case map[interface{}]interface{}:
+        for key, child := range v {
+            err := flatten(join(key), child, set)
+            if err != nil {
+                return err
+            }
+        }
+    case map[string]interface{}:
+        for key, child := range v {
+            err := flatten(join(key), child, set)
+            if err != nil {
+                return err
+            }
+        }
+    case []interface{}:
+        for _, child := range v {
+            err := flatten(prefix, child, set)
+            if err != nil {
+                return err
+            }
+        }
+    case nil:
+        if prefix != "" {
+            return set(prefix, "")
+        }
+    default:
+        if prefix == "" {
+            return fmt.Errorf("%wexpected a map of flags", ErrFailedToReadConfig)
+        }
+        return set(prefix, fmt.Sprint(v))
+    }
+    return nil
+}
+
+type File struct {
+    path *string
+}
+
+// NewFile takes a pointer so the path can come from a flag that hasn't been
+// parsed yet.
+func NewFile(path *string) File {
+    return File{path: path}
+}
+
+func (f File) Path() string {
+    return *f.path
+}
+
+// Set writes key = value into the section of the config file, creating the
+// file if it doesn't exist and keeping everything else in it.
+func (f File) Set(section, key, value string) error {
+    path := f.Path()
+    contents, err := os.ReadFile(path)
+    if err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+    }
+
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".toml":
+        tree, err := toml.LoadBytes(contents)
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+        }
+        tree.SetPath([]string{section, key}, value)
+        contents = []byte(tree.String())
+    case ".yaml", ".yml":
+        doc := yaml.MapSlice{}
+        err = yaml.Unmarshal(contents, &doc)
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+        }
+        contents, err = yaml.Marshal(setInMapSlice(doc, section, key, value))
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+        }
+    case ".json":
+        doc := make(map[string]interface{})
+        if len(bytes.TrimSpace(contents)) > 0 {
+            err = json.Unmarshal(contents, &doc)
+            if err != nil {
+                return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+            }
+        }
+        sectionValues, ok := doc[section].(map[string]interface{})
+        if !ok {
+            sectionValues = make(map[string]interface{})
+        }
+        sectionValues[key] = value
+        doc[section] = sectionValues
+        contents, err = json.MarshalIndent(doc, "", "  ")
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+        }
+    default:
+        return fmt.Errorf("%w%s", ErrUnsupportedConfigFormat, path)
+    }
+
+    err = os.MkdirAll(filepath.Dir(path), 0700)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+    }
+    err = os.WriteFile(path, contents, 0600)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteConfig, err)
+    }
+    return nil
+}
+
+func setInMapSlice(doc yaml.MapSlice, section, key, value string) yaml.MapSlice {
+    for i, item := range doc {
+        if item.Key != section {
+            continue
+        }
+        values, _ := item.Value.(yaml.MapSlice)
+        for j, existing := range values {
+            if existing.Key == key {
+                values[j].Value = value
+                doc[i].Value = values
+                return doc
+            }
+        }
+        doc[i].Value = append(values, yaml.MapItem{Key: key, Value: value})
+        return doc
+    }
+    return append(doc, yaml.MapItem{Key: section, Value: yaml.MapSlice{{Key: key, Value: value}}})
+}
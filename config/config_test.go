@@ -0,0 +1,158 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/config"
+	"github.com/peterbourgon/ff/v3"
+)
+
+type testFlags struct {
+    dbID string
+    key string
+    groupBy string
+    tags []string
+}
+
+func newTestFlagSet(flags *testFlags) *flag.FlagSet {
+    fs := flag.NewFlagSet("test", flag.ContinueOnError)
+    fs.StringVar(&flags.dbID, "d", "", "")
+    fs.StringVar(&flags.key, "k", "", "")
+    fs.StringVar(&flags.groupBy, "group-by", "day", "")
+    fs.Func("include-tag", "", func(value string) error {
+        flags.tags = append(flags.tags, value)
+        return nil
+    })
+    return fs
+}
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), name)
+    err := os.WriteFile(path, []byte(contents), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestConfigFilesSetFlagsWithCommandSectionsWinning(t *testing.T) {
+    testCases := []struct{
+        name string
+        file string
+        contents string
+    }{
+        {
+            name: "toml",
+            file: "config.toml",
+            contents: "d = \"globaldb\"\nk = \"globalkey\"\n\n[notion]\nd = \"notiondb\"\ninclude-tag = [\"work\", \"health\"]\n\n[joplin]\nk = \"joplinkey\"\n",
+        },
+        {
+            name: "yaml",
+            file: "config.yaml",
+            contents: "d: globaldb\nk: globalkey\nnotion:\n  d: notiondb\n  include-tag: [work, health]\njoplin:\n  k: joplinkey\n",
+        },
+        {
+            name: "json",
+            file: "config.json",
+            contents: `{"d": "globaldb", "k": "globalkey", "notion": {"d": "notiondb", "include-tag": ["work", "health"]}, "joplin": {"k": "joplinkey"}}`,
+        },
+    }
+
+    for _, testCase := range testCases {
+        t.Run(testCase.name, func(t *testing.T) {
+            path := writeConfigFile(t, testCase.file, testCase.contents)
+            flags := &testFlags{}
+            err := ff.Parse(newTestFlagSet(flags), []string{}, config.Options("notion", &path)...)
+            if err != nil {
+                t.Fatal(err)
+            }
+            expected := &testFlags{dbID: "notiondb", key: "globalkey", groupBy: "day", tags: []string{"work", "health"}}
+            if !reflect.DeepEqual(expected, flags) {
+                t.Errorf("Expected flags to be %+v, got %+v", expected, flags)
+            }
+        })
+    }
+}
+
+func TestFlagsWinOverEnvVarsWhichWinOverTheConfigFile(t *testing.T) {
+    path := writeConfigFile(t, "config.toml", "[notion]\nd = \"filedb\"\nk = \"filekey\"\ngroup-by = \"week\"\n")
+    t.Setenv("JRNLSYNC_K", "envkey")
+    t.Setenv("JRNLSYNC_GROUP_BY", "month")
+
+    flags := &testFlags{}
+    err := ff.Parse(newTestFlagSet(flags), []string{"-group-by", "entry"}, config.Options("notion", &path)...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := &testFlags{dbID: "filedb", key: "envkey", groupBy: "entry"}
+    if !reflect.DeepEqual(expected, flags) {
+        t.Errorf("Expected flags to be %+v, got %+v", expected, flags)
+    }
+}
+
+func TestAMissingConfigFileIsIgnored(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "config.toml")
+    flags := &testFlags{}
+    err := ff.Parse(newTestFlagSet(flags), []string{"-d", "flagdb"}, config.Options("notion", &path)...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if flags.dbID != "flagdb" {
+        t.Errorf("Expected d to be %q, got %q", "flagdb", flags.dbID)
+    }
+}
+
+func TestSetKeepsTheRestOfTheConfigFile(t *testing.T) {
+    testCases := []struct{
+        name string
+        file string
+        contents string
+    }{
+        {name: "toml", file: "config.toml", contents: "group-by = \"week\"\n\n[notion]\nprofile = \"old\"\nd = \"notiondb\"\n"},
+        {name: "yaml", file: "config.yaml", contents: "group-by: week\nnotion:\n  profile: old\n  d: notiondb\n"},
+        {name: "json", file: "config.json", contents: `{"group-by": "week", "notion": {"profile": "old", "d": "notiondb"}}`},
+    }
+
+    for _, testCase := range testCases {
+        t.Run(testCase.name, func(t *testing.T) {
+            path := writeConfigFile(t, testCase.file, testCase.contents)
+            err := config.NewFile(&path).Set("notion", "profile", "work")
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            fs := flag.NewFlagSet("notion", flag.ContinueOnError)
+            profileName := fs.String("profile", "", "")
+            dbID := fs.String("d", "", "")
+            groupBy := fs.String("group-by", "", "")
+            err = ff.Parse(fs, []string{}, config.Options("notion", &path)...)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if *profileName != "work" || *dbID != "notiondb" || *groupBy != "week" {
+                t.Errorf("Expected profile, d and group-by to be work, notiondb and week, got %s, %s and %s", *profileName, *dbID, *groupBy)
+            }
+        })
+    }
+}
+
+func TestSetCreatesTheConfigFile(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "jrnlSync", "config.toml")
+    err := config.NewFile(&path).Set("notion", "profile", "default")
+    if err != nil {
+        t.Fatal(err)
+    }
+    contents, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "\n[notion]\n  profile = \"default\"\n"
+    if string(contents) != expected {
+        t.Errorf("Expected the config file to be %q, got %q", expected, string(contents))
+    }
+}
@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/setup"
+	"github.com/peterbourgon/ff/v3"
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+// the longest the daemon sleeps before looking at the wall clock again, timers
+// don't count time the machine spends asleep so a sync due while it was asleep
+// would otherwise run late
+const pollInterval = time.Minute
+
+var ErrFailedToReloadConfig = errors.New("failed to reload the daemon config: ")
+
+type Settings struct {
+    Schedule string
+    StatusFile string
+    ShowStatus bool
+}
+
+type Config struct {
+    Settings
+    Run func(ctx context.Context, args []string) error
+    Out io.Writer
+    Now func() time.Time
+    After func(d time.Duration) <-chan time.Time
+    Signals chan os.Signal
+    command *ffcli.Command
+    pinned map[string]string
+}
+
+func (s *Settings) RegisterFlags(fs *flag.FlagSet) {
+    fs.StringVar(&s.Schedule, "schedule", setup.DefaultSchedule, "When to sync, nightly, hourly, weekly or a cron expression like '30 6 * * 1-5'")
+    fs.StringVar(&s.StatusFile, "status-file", DefaultStatusPath(), "Where the daemon keeps the time and result of the last sync")
+    fs.BoolVar(&s.ShowStatus, "status", false, "Print the status of the running daemon and exit")
+}
+
+func NewConfig(run func(ctx context.Context, args []string) error, out io.Writer) *Config {
+    return &Config{
+        Run: run,
+        Out: out,
+        Now: time.Now,
+        After: time.After,
+    }
+}
+
+func NewDaemonFlagSet(c *Config) *ffcli.Command {
+    daemonFlagSet := flag.NewFlagSet("jrnlSync daemon", flag.ExitOnError)
+    c.Settings.RegisterFlags(daemonFlagSet)
+
+    c.command = &ffcli.Command{
+        Name:       "daemon",
+        ShortUsage: "jrnlSync daemon [flags] [command [command flags]]",
+        ShortHelp:  "Stays running in the foreground and syncs on a schedule, runs notion unless given another command",
+        FlagSet:    daemonFlagSet,
+        Exec:       c.Exec,
+    }
+    return c.command
+}
+
+// ExecRunner runs each sync as a new jrnlSync process using the same config
+// file, so the file is read again and "yesterday" is worked out fresh for every
+// sync.
+func ExecRunner(executable string, configFile *string, out io.Writer) func(ctx context.Context, args []string) error {
+    return func(ctx context.Context, args []string) error {
+        cmd := exec.CommandContext(ctx, executable, append([]string{"-config", *configFile}, args...)...)
+        cmd.Stdout = out
+        cmd.Stderr = out
+        return cmd.Run()
+    }
+}
+
+func (c *Config) Exec(ctx context.Context, args []string) error {
+    if c.ShowStatus {
+        return printStatus(c.Out, c.StatusFile)
+    }
+    schedule, err := setup.ParseSchedule(c.Schedule)
+    if err != nil {
+        return err
+    }
+    if len(args) == 0 {
+        args = []string{"notion"}
+    }
+    c.pinned = c.commandLineFlags()
+
+    if c.Signals == nil {
+        c.Signals = make(chan os.Signal, 1)
+        signal.Notify(c.Signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+        defer signal.Stop(c.Signals)
+    }
+
+    status := readStatus(c.StatusFile)
+    status.PID = os.Getpid()
+    status.Schedule = schedule.String()
+    // a sync missed while the daemon wasn't running, or that failed before it
+    // stopped, is caught up straight away
+    next := schedule.Next(c.Now())
+    if since := status.LastSuccess; since != nil || status.LastRun != nil {
+        if since == nil {
+            since = status.LastRun
+        }
+        if missed := schedule.Next(*since); missed.Before(next) {
+            next = missed
+        }
+    }
+    fmt.Fprintf(c.Out, "jrnlSync daemon started, syncing at %q, next sync at %s\n", schedule, next.Format(time.RFC3339))
+
+    waitFor := func(at time.Time) {
+        next = at
+        status.State = stateWaiting
+        status.NextRun = next
+        c.saveStatus(status)
+    }
+    handleSignal := func(sig os.Signal) bool {
+        if sig != syscall.SIGHUP {
+            return true
+        }
+        reloaded, err := c.reload()
+        if err != nil {
+            fmt.Fprintf(c.Out, "%s, keeping %q\n", err, schedule)
+            return false
+        }
+        schedule = reloaded
+        status.Schedule = schedule.String()
+        waitFor(schedule.Next(c.Now()))
+        fmt.Fprintf(c.Out, "reloaded the config, syncing at %q, next sync at %s\n", schedule, next.Format(time.RFC3339))
+        return false
+    }
+
+    waitFor(next)
+
+    for {
+        // signals that came in during a sync are dealt with before anything else
+        select {
+        case sig := <-c.Signals:
+            if handleSignal(sig) {
+                return c.stop(status, sig)
+            }
+            continue
+        case <-ctx.Done():
+            return c.stop(status, nil)
+        default:
+        }
+
+        // wall clock time so time spent asleep counts
+        now := c.Now().Round(0)
+        if now.Before(next) {
+            wait := next.Sub(now)
+            if wait > pollInterval {
+                wait = pollInterval
+            }
+            select {
+            case <-c.After(wait):
+            case sig := <-c.Signals:
+                if handleSignal(sig) {
+                    return c.stop(status, sig)
+                }
+            case <-ctx.Done():
+                return c.stop(status, nil)
+            }
+            continue
+        }
+
+        status.State = stateSyncing
+        c.saveStatus(status)
+        fmt.Fprintf(c.Out, "syncing, it was due at %s\n", next.Format(time.RFC3339))
+        err := c.Run(ctx, syncArgs(args, schedule, status.LastSuccess, next, now))
+        status.setResult(now, err)
+        if err != nil {
+            fmt.Fprintf(c.Out, "sync failed: %s\n", err)
+        }
+        if ctx.Err() != nil {
+            return c.stop(status, nil)
+        }
+        // one sync catches up every date since the last one that worked
+        waitFor(schedule.Next(c.Now()))
+    }
+}
+
+// syncArgs has notion and joplin sync every date since the last sync that
+// worked, or the dates the sync that was due would have covered when none has,
+// unless they were told which dates to sync.
+func syncArgs(args []string, schedule setup.Schedule, lastSuccess *time.Time, due, now time.Time) []string {
+    if args[0] != "notion" && args[0] != "joplin" {
+        return args
+    }
+    for _, arg := range args[1:] {
+        name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+        if strings.HasPrefix(arg, "-") && (name == "date" || name == "days") {
+            return args
+        }
+    }
+    since := due.AddDate(0, 0, 1-schedule.CoverDays())
+    if lastSuccess != nil {
+        since = *lastSuccess
+    }
+    days := daysBetween(since, now) + 1
+    if days < 1 {
+        days = 1
+    }
+    return append([]string{args[0], "-days", strconv.Itoa(days)}, args[1:]...)
+}
+
+// daysBetween counts calendar days so a change to or from summer time doesn't
+// lose one
+func daysBetween(from, to time.Time) int {
+    from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+    to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+    return int(to.Sub(from).Hours() / 24)
+}
+
+func (c *Config) stop(status Status, sig os.Signal) error {
+    if sig != nil {
+        fmt.Fprintf(c.Out, "got %s, stopping\n", sig)
+    }
+    status.State = stateStopped
+    c.saveStatus(status)
+    return nil
+}
+
+func (c *Config) saveStatus(status Status) {
+    err := writeStatus(c.StatusFile, status)
+    if err != nil {
+        fmt.Fprintln(c.Out, err)
+    }
+}
+
+// commandLineFlags works out which flags were given on the command line so a
+// reload doesn't swap them for what's in the config file. They're the ones that
+// don't have the value the config file, env vars or default would give them.
+func (c *Config) commandLineFlags() map[string]string {
+    pinned := make(map[string]string)
+    fs, err := c.parseSettings(&Settings{})
+    if err != nil {
+        return pinned
+    }
+    c.command.FlagSet.VisitAll(func(f *flag.Flag) {
+        if fs.Lookup(f.Name).Value.String() != f.Value.String() {
+            pinned[f.Name] = f.Value.String()
+        }
+    })
+    return pinned
+}
+
+func (c *Config) parseSettings(s *Settings) (*flag.FlagSet, error) {
+    fs := flag.NewFlagSet("jrnlSync daemon", flag.ContinueOnError)
+    fs.SetOutput(io.Discard)
+    s.RegisterFlags(fs)
+    err := ff.Parse(fs, []string{}, c.command.Options...)
+    return fs, err
+}
+
+func (c *Config) reload() (setup.Schedule, error) {
+    s := Settings{}
+    fs, err := c.parseSettings(&s)
+    for name, value := range c.pinned {
+        if err == nil {
+            err = fs.Set(name, value)
+        }
+    }
+    if err != nil {
+        return setup.Schedule{}, fmt.Errorf("%w%s", ErrFailedToReloadConfig, err)
+    }
+    schedule, err := setup.ParseSchedule(s.Schedule)
+    if err != nil {
+        return setup.Schedule{}, fmt.Errorf("%w%s", ErrFailedToReloadConfig, err)
+    }
+    s.ShowStatus = c.ShowStatus
+    c.Settings = s
+    return schedule, nil
+}
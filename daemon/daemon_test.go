@@ -0,0 +1,281 @@
+package daemon_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/config"
+	"github.com/jm96441n/jrnlSync/daemon"
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type fakeClock struct {
+    now time.Time
+    onWait func(now time.Time)
+}
+
+func (f *fakeClock) Now() time.Time {
+    return f.now
+}
+
+// After moves the clock on straight away, like the daemon slept for d
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+    f.now = f.now.Add(d)
+    if f.onWait != nil {
+        f.onWait(f.now)
+    }
+    ch := make(chan time.Time, 1)
+    ch <- f.now
+    return ch
+}
+
+type recordingRunner struct {
+    clock *fakeClock
+    runs []time.Time
+    args [][]string
+    stopAfter int
+    signals chan os.Signal
+    err error
+}
+
+func (r *recordingRunner) run(_ context.Context, args []string) error {
+    r.runs = append(r.runs, r.clock.now)
+    r.args = append(r.args, args)
+    if len(r.runs) == r.stopAfter {
+        r.signals <- syscall.SIGTERM
+    }
+    return r.err
+}
+
+func newTestDaemon(t *testing.T, start time.Time, stopAfter int, configContents string) (*daemon.Config, *ffcli.Command, *fakeClock, *recordingRunner, string) {
+    t.Helper()
+    dir := t.TempDir()
+    clock := &fakeClock{now: start}
+    signals := make(chan os.Signal, 1)
+    runner := &recordingRunner{clock: clock, stopAfter: stopAfter, signals: signals}
+    c := daemon.NewConfig(runner.run, bytes.NewBuffer([]byte{}))
+    c.Now = clock.Now
+    c.After = clock.After
+    c.Signals = signals
+
+    configFile := filepath.Join(dir, "config.toml")
+    err := os.WriteFile(configFile, []byte(configContents), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    command := daemon.NewDaemonFlagSet(c)
+    command.Options = config.Options("daemon", &configFile)
+    return c, command, clock, runner, configFile
+}
+
+func formatRuns(runs []time.Time) []string {
+    formatted := make([]string, 0, len(runs))
+    for _, run := range runs {
+        formatted = append(formatted, run.Format("2006-01-02 15:04"))
+    }
+    return formatted
+}
+
+func TestDaemonSyncsOnTheSchedule(t *testing.T) {
+    start := time.Date(2021, time.November, 24, 23, 30, 0, 0, time.UTC)
+    _, command, _, runner, _ := newTestDaemon(t, start, 3, "")
+    statusFile := filepath.Join(t.TempDir(), "status.json")
+    err := command.ParseAndRun(context.Background(), []string{"-status-file", statusFile})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{"2021-11-25 00:01", "2021-11-26 00:01", "2021-11-27 00:01"}
+    if !reflect.DeepEqual(expected, formatRuns(runner.runs)) {
+        t.Errorf("Expected syncs at %v, got %v", expected, formatRuns(runner.runs))
+    }
+    if !reflect.DeepEqual([]string{"notion", "-days", "2"}, runner.args[0]) {
+        t.Errorf("Expected the notion command to be run for yesterday and today, got %v", runner.args[0])
+    }
+}
+
+func TestDaemonRunsTheGivenCommand(t *testing.T) {
+    start := time.Date(2021, time.November, 24, 23, 30, 0, 0, time.UTC)
+    _, command, _, runner, _ := newTestDaemon(t, start, 1, "")
+    args := []string{"-status-file", filepath.Join(t.TempDir(), "status.json"), "-schedule", "hourly", "joplin", "-n", "notebook"}
+    err := command.ParseAndRun(context.Background(), args)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual([]string{"joplin", "-days", "2", "-n", "notebook"}, runner.args[0]) {
+        t.Errorf("Expected the joplin command to be run, got %v", runner.args[0])
+    }
+    if formatRuns(runner.runs)[0] != "2021-11-25 00:00" {
+        t.Errorf("Expected the hourly sync at 00:00, got %v", formatRuns(runner.runs))
+    }
+}
+
+func TestDaemonCatchesUpOnceAfterSleeping(t *testing.T) {
+    start := time.Date(2021, time.November, 24, 23, 30, 0, 0, time.UTC)
+    _, command, clock, runner, _ := newTestDaemon(t, start, 2, "")
+    slept := false
+    clock.onWait = func(now time.Time) {
+        // asleep for three days straight after starting
+        if !slept {
+            slept = true
+            clock.now = now.Add(72 * time.Hour)
+        }
+    }
+    err := command.ParseAndRun(context.Background(), []string{"-status-file", filepath.Join(t.TempDir(), "status.json")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{"2021-11-27 23:31", "2021-11-28 00:01"}
+    if !reflect.DeepEqual(expected, formatRuns(runner.runs)) {
+        t.Errorf("Expected syncs at %v, got %v", expected, formatRuns(runner.runs))
+    }
+    // the catch up takes in the 24th, which the missed sync was due to cover
+    expectedArgs := [][]string{{"notion", "-days", "4"}, {"notion", "-days", "2"}}
+    if !reflect.DeepEqual(expectedArgs, runner.args) {
+        t.Errorf("Expected syncs of %v, got %v", expectedArgs, runner.args)
+    }
+}
+
+func TestDaemonCatchesUpASyncMissedWhileItWasStopped(t *testing.T) {
+    statusFile := filepath.Join(t.TempDir(), "status.json")
+    lastRun := time.Date(2021, time.November, 22, 0, 1, 0, 0, time.UTC)
+    contents, err := json.Marshal(daemon.Status{State: "stopped", LastRun: &lastRun, LastResult: "ok"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = os.WriteFile(statusFile, contents, 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    start := time.Date(2021, time.November, 24, 12, 0, 0, 0, time.UTC)
+    _, command, _, runner, _ := newTestDaemon(t, start, 1, "")
+    err = command.ParseAndRun(context.Background(), []string{"-status-file", statusFile})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{"2021-11-24 12:00"}
+    if !reflect.DeepEqual(expected, formatRuns(runner.runs)) {
+        t.Errorf("Expected syncs at %v, got %v", expected, formatRuns(runner.runs))
+    }
+    if !reflect.DeepEqual([]string{"notion", "-days", "3"}, runner.args[0]) {
+        t.Errorf("Expected every date since the last sync to be synced, got %v", runner.args[0])
+    }
+}
+
+func TestDaemonTriesASyncThatFailedBeforeItStoppedAgain(t *testing.T) {
+    statusFile := filepath.Join(t.TempDir(), "status.json")
+    lastSuccess := time.Date(2021, time.November, 23, 0, 1, 0, 0, time.UTC)
+    lastRun := time.Date(2021, time.November, 24, 0, 1, 0, 0, time.UTC)
+    contents, err := json.Marshal(daemon.Status{State: "stopped", LastRun: &lastRun, LastSuccess: &lastSuccess, LastResult: "failed", LastError: "notion is down"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = os.WriteFile(statusFile, contents, 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    start := time.Date(2021, time.November, 24, 12, 0, 0, 0, time.UTC)
+    _, command, _, runner, _ := newTestDaemon(t, start, 1, "")
+    err = command.ParseAndRun(context.Background(), []string{"-status-file", statusFile, "notion", "-group-by", "week"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{"2021-11-24 12:00"}
+    if !reflect.DeepEqual(expected, formatRuns(runner.runs)) {
+        t.Errorf("Expected syncs at %v, got %v", expected, formatRuns(runner.runs))
+    }
+    if !reflect.DeepEqual([]string{"notion", "-days", "2", "-group-by", "week"}, runner.args[0]) {
+        t.Errorf("Expected the dates since the last sync that worked to be synced, got %v", runner.args[0])
+    }
+}
+
+func TestDaemonKeepsGoingAfterAFailedSyncAndRecordsIt(t *testing.T) {
+    statusFile := filepath.Join(t.TempDir(), "status.json")
+    start := time.Date(2021, time.November, 24, 23, 30, 0, 0, time.UTC)
+    _, command, _, runner, _ := newTestDaemon(t, start, 2, "")
+    runner.err = errors.New("notion is down")
+    err := command.ParseAndRun(context.Background(), []string{"-status-file", statusFile})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(runner.runs) != 2 {
+        t.Fatalf("Expected 2 syncs, got %d", len(runner.runs))
+    }
+
+    contents, err := os.ReadFile(statusFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    status := daemon.Status{}
+    err = json.Unmarshal(contents, &status)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if status.State != "stopped" || status.LastResult != "failed" || status.LastError != "notion is down" {
+        t.Errorf("Expected the status to be stopped after a failed sync, got %+v", status)
+    }
+    if status.LastRun == nil || !status.LastRun.Equal(runner.runs[1]) {
+        t.Errorf("Expected the last sync to be at %s, got %v", runner.runs[1], status.LastRun)
+    }
+
+    out := bytes.NewBuffer([]byte{})
+    err = daemon.NewDaemonFlagSet(daemon.NewConfig(runner.run, out)).ParseAndRun(context.Background(), []string{"-status-file", statusFile, "-status"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "state: stopped (pid " + strconv.Itoa(os.Getpid()) + ")\nschedule: 1 0 * * *\nlast sync: 2021-11-26T00:01:00Z, failed: notion is down\nlast successful sync: never\n"
+    if out.String() != expected {
+        t.Errorf("Expected the status to print %q, got %q", expected, out.String())
+    }
+}
+
+func TestDaemonReloadsTheConfigOnSIGHUP(t *testing.T) {
+    start := time.Date(2021, time.November, 24, 22, 30, 0, 0, time.UTC)
+    c, command, clock, runner, configFile := newTestDaemon(t, start, 2, "[daemon]\nschedule = \"nightly\"\n")
+    reloaded := false
+    clock.onWait = func(time.Time) {
+        if !reloaded {
+            reloaded = true
+            err := os.WriteFile(configFile, []byte("[daemon]\nschedule = \"hourly\"\n"), 0600)
+            if err != nil {
+                t.Fatal(err)
+            }
+            c.Signals <- syscall.SIGHUP
+        }
+    }
+    err := command.ParseAndRun(context.Background(), []string{"-status-file", filepath.Join(t.TempDir(), "status.json")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{"2021-11-24 23:00", "2021-11-25 00:00"}
+    if !reflect.DeepEqual(expected, formatRuns(runner.runs)) {
+        t.Errorf("Expected syncs at %v, got %v", expected, formatRuns(runner.runs))
+    }
+}
+
+func TestDaemonStopsWhenTheContextIsCancelled(t *testing.T) {
+    start := time.Date(2021, time.November, 24, 23, 30, 0, 0, time.UTC)
+    _, command, clock, runner, _ := newTestDaemon(t, start, 0, "")
+    ctx, cancel := context.WithCancel(context.Background())
+    clock.onWait = func(time.Time) {
+        cancel()
+    }
+    err := command.ParseAndRun(ctx, []string{"-status-file", filepath.Join(t.TempDir(), "status.json")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(runner.runs) != 0 {
+        t.Errorf("Expected no syncs, got %v", formatRuns(runner.runs))
+    }
+}
+
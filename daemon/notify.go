@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// NotifyWatcher sends the name of each file that changes in a journal file or
+// folder journal. The folder a journal file is in is watched rather than the
+// file itself, editors and jrnl save by writing a new file over the old one
+// which would otherwise drop the watch.
+func NotifyWatcher(ctx context.Context, path string) (<-chan string, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToWatchJournal, err)
+    }
+    watcher, err := fsnotify.NewWatcher()
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToWatchJournal, err)
+    }
+    folder := info.IsDir()
+    if folder {
+        err = watchTree(watcher, path)
+    } else {
+        err = watcher.Add(filepath.Dir(path))
+    }
+    if err != nil {
+        watcher.Close()
+        return nil, fmt.Errorf("%w%s", ErrFailedToWatchJournal, err)
+    }
+
+    path = filepath.Clean(path)
+    changes := make(chan string)
+    send := func(name string) bool {
+        select {
+        case changes <- name:
+            return true
+        case <-ctx.Done():
+            return false
+        }
+    }
+    go func() {
+        defer close(changes)
+        defer watcher.Close()
+        for {
+            select {
+            case event, ok := <-watcher.Events:
+                if !ok {
+                    return
+                }
+                if event.Op == fsnotify.Chmod || (!folder && filepath.Clean(event.Name) != path) {
+                    continue
+                }
+                // new year and month folders need watching too
+                if folder && event.Op&fsnotify.Create != 0 {
+                    if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+                        watchTree(watcher, event.Name)
+                    }
+                }
+                if !send(event.Name) {
+                    return
+                }
+            case _, ok := <-watcher.Errors:
+                if !ok {
+                    return
+                }
+                // a dropped event could have been a change so look anyway
+                if !send(path) {
+                    return
+                }
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+    return changes, nil
+}
+
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+    return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() {
+            return watcher.Add(path)
+        }
+        return nil
+    })
+}
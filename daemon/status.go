@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+    stateWaiting = "waiting"
+    stateSyncing = "syncing"
+    stateStopped = "stopped"
+)
+
+var ErrFailedToWriteStatus = errors.New("failed to write the daemon status: ")
+var ErrFailedToReadStatus = errors.New("failed to read the daemon status: ")
+
+type Status struct {
+    PID int `json:"pid"`
+    State string `json:"state"`
+    Schedule string `json:"schedule"`
+    LastRun *time.Time `json:"last_run,omitempty"`
+    LastSuccess *time.Time `json:"last_success,omitempty"`
+    LastResult string `json:"last_result,omitempty"`
+    LastError string `json:"last_error,omitempty"`
+    NextRun time.Time `json:"next_run"`
+}
+
+func DefaultStatusPath() string {
+    stateHome := os.Getenv("XDG_STATE_HOME")
+    if stateHome == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return filepath.Join(".local", "state", "jrnlSync", "daemon.json")
+        }
+        stateHome = filepath.Join(home, ".local", "state")
+    }
+    return filepath.Join(stateHome, "jrnlSync", "daemon.json")
+}
+
+func (s *Status) setResult(ranAt time.Time, err error) {
+    s.LastRun = &ranAt
+    s.LastResult = "ok"
+    s.LastError = ""
+    if err == nil {
+        s.LastSuccess = &ranAt
+    } else {
+        s.LastResult = "failed"
+        s.LastError = err.Error()
+    }
+}
+
+// readStatus starts from an empty status when there isn't one yet
+func readStatus(path string) Status {
+    status := Status{}
+    contents, err := os.ReadFile(path)
+    if err == nil {
+        json.Unmarshal(contents, &status)
+    }
+    // status files from before last_success was kept
+    if status.LastSuccess == nil && status.LastResult == "ok" {
+        status.LastSuccess = status.LastRun
+    }
+    return status
+}
+
+func writeStatus(path string, status Status) error {
+    contents, err := json.MarshalIndent(status, "", "  ")
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteStatus, err)
+    }
+    err = os.MkdirAll(filepath.Dir(path), 0700)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteStatus, err)
+    }
+    // renamed into place so -status never reads half a file
+    tmp := path + ".tmp"
+    err = os.WriteFile(tmp, append(contents, '\n'), 0600)
+    if err == nil {
+        err = os.Rename(tmp, path)
+    }
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteStatus, err)
+    }
+    return nil
+}
+
+func printStatus(out io.Writer, path string) error {
+    contents, err := os.ReadFile(path)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToReadStatus, err)
+    }
+    status := Status{}
+    err = json.Unmarshal(contents, &status)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToReadStatus, err)
+    }
+
+    fmt.Fprintf(out, "state: %s (pid %d)\n", status.State, status.PID)
+    fmt.Fprintf(out, "schedule: %s\n", status.Schedule)
+    if status.LastRun == nil {
+        fmt.Fprint(out, "last sync: never\n")
+    } else if status.LastError != "" {
+        fmt.Fprintf(out, "last sync: %s, %s: %s\n", status.LastRun.Format(time.RFC3339), status.LastResult, status.LastError)
+        if status.LastSuccess == nil {
+            fmt.Fprint(out, "last successful sync: never\n")
+        } else {
+            fmt.Fprintf(out, "last successful sync: %s\n", status.LastSuccess.Format(time.RFC3339))
+        }
+    } else {
+        fmt.Fprintf(out, "last sync: %s, %s\n", status.LastRun.Format(time.RFC3339), status.LastResult)
+    }
+    if status.State != stateStopped {
+        fmt.Fprintf(out, "next sync: %s\n", status.NextRun.Format(time.RFC3339))
+    }
+    return nil
+}
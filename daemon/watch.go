@@ -0,0 +1,201 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/jrnl"
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+const DefaultDebounce = 30 * time.Second
+
+var ErrNoJournalToWatch = errors.New("couldn't find a journal file to watch, pass -journal or -journal-file")
+var ErrUnsupportedWatchCommand = errors.New("watch can only run notion or joplin, they update the page for a date in place: ")
+var ErrFailedToWatchJournal = errors.New("failed to watch the journal: ")
+
+type WatchConfig struct {
+    Source *jrnl.Source
+    Debounce time.Duration
+    Run func(ctx context.Context, args []string) error
+    Watch func(ctx context.Context, path string) (<-chan string, error)
+    Out io.Writer
+    After func(d time.Duration) <-chan time.Time
+    Signals chan os.Signal
+    command *ffcli.Command
+}
+
+func NewWatchConfig(source *jrnl.Source, run func(ctx context.Context, args []string) error, out io.Writer) *WatchConfig {
+    return &WatchConfig{
+        Source: source,
+        Run: run,
+        Watch: NotifyWatcher,
+        Out: out,
+        After: time.After,
+    }
+}
+
+func NewWatchFlagSet(c *WatchConfig) *ffcli.Command {
+    watchFlagSet := flag.NewFlagSet("jrnlSync watch", flag.ExitOnError)
+    watchFlagSet.DurationVar(&c.Debounce, "debounce", DefaultDebounce, "How long the journal has to go without changing before it's synced")
+    c.Source.RegisterFlags(watchFlagSet)
+
+    c.command = &ffcli.Command{
+        Name:       "watch",
+        ShortUsage: "jrnlSync watch [flags] [notion|joplin [command flags]]",
+        ShortHelp:  "Stays running in the foreground and syncs the dates that changed soon after the journal is saved",
+        FlagSet:    watchFlagSet,
+        Exec:       c.Exec,
+    }
+    return c.command
+}
+
+func (c *WatchConfig) Exec(ctx context.Context, args []string) error {
+    if len(args) == 0 {
+        args = []string{"notion"}
+    }
+    if args[0] != "notion" && args[0] != "joplin" {
+        return fmt.Errorf("%w%q", ErrUnsupportedWatchCommand, args[0])
+    }
+
+    // jrnl's default journal is the one the sync commands read when they're
+    // not told which, so it's the one to watch
+    source := *c.Source
+    if source.Journal == "" && source.JournalFile == "" {
+        source.Journal = jrnl.DefaultJournalName
+    }
+    settings, err := source.Settings()
+    if err != nil {
+        return err
+    }
+    if settings.Path == "" {
+        return ErrNoJournalToWatch
+    }
+    hashes, err := hashEntriesByDate(&source)
+    if err != nil {
+        return err
+    }
+
+    if c.Signals == nil {
+        c.Signals = make(chan os.Signal, 1)
+        signal.Notify(c.Signals, syscall.SIGTERM, syscall.SIGINT)
+        defer signal.Stop(c.Signals)
+    }
+    watchCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    changes, err := c.Watch(watchCtx, settings.Path)
+    if err != nil {
+        return err
+    }
+    fmt.Fprintf(c.Out, "jrnlSync watching %s, syncing %s after it's been quiet for %s\n", settings.Path, args[0], c.Debounce)
+
+    // each change starts the wait over so a burst of writes is synced once
+    var quiet <-chan time.Time
+    for {
+        select {
+        case _, ok := <-changes:
+            if !ok {
+                if ctx.Err() != nil {
+                    return nil
+                }
+                return fmt.Errorf("%w%s", ErrFailedToWatchJournal, "stopped getting notifications")
+            }
+            quiet = c.After(c.Debounce)
+        case <-quiet:
+            quiet = nil
+            c.syncChanges(ctx, &source, args, hashes)
+        case sig := <-c.Signals:
+            fmt.Fprintf(c.Out, "got %s, stopping\n", sig)
+            return nil
+        case <-ctx.Done():
+            return nil
+        }
+    }
+}
+
+// syncChanges syncs each date whose entries were added to or edited since the
+// last look. Dates that fail keep their old hash so they're tried again after
+// the next change.
+func (c *WatchConfig) syncChanges(ctx context.Context, source *jrnl.Source, args []string, hashes map[string]string) {
+    current, err := hashEntriesByDate(source)
+    if err != nil {
+        fmt.Fprintf(c.Out, "%s\n", err)
+        return
+    }
+    dates := make([]string, 0)
+    for date, hash := range current {
+        if hashes[date] != hash {
+            dates = append(dates, date)
+        }
+    }
+    sort.Strings(dates)
+    if len(dates) == 0 {
+        fmt.Fprint(c.Out, "the journal changed but none of its entries did, nothing to sync\n")
+    }
+
+    for _, date := range dates {
+        fmt.Fprintf(c.Out, "syncing %s\n", date)
+        runArgs := append([]string{args[0], "-date", date}, c.sourceArgs()...)
+        err := c.Run(ctx, append(runArgs, args[1:]...))
+        if err != nil {
+            fmt.Fprintf(c.Out, "sync of %s failed: %s\n", date, err)
+            continue
+        }
+        hashes[date] = current[date]
+    }
+    // a date with no entries left isn't synced, its page is left as it was
+    for date := range hashes {
+        if _, ok := current[date]; !ok {
+            delete(hashes, date)
+        }
+    }
+}
+
+// sourceArgs passes on the journal flags watch was given so the sync reads the
+// same journal that's being watched
+func (c *WatchConfig) sourceArgs() []string {
+    sourceFlags := flag.NewFlagSet("", flag.ContinueOnError)
+    (&jrnl.Source{}).RegisterFlags(sourceFlags)
+    args := make([]string, 0)
+    c.command.FlagSet.Visit(func(f *flag.Flag) {
+        if sourceFlags.Lookup(f.Name) != nil {
+            args = append(args, fmt.Sprintf("-%s=%s", f.Name, f.Value))
+        }
+    })
+    return args
+}
+
+func hashEntriesByDate(source *jrnl.Source) (map[string]string, error) {
+    output, err := source.Output()
+    if err != nil {
+        return nil, err
+    }
+    journal := jrnl.Journal{}
+    err = json.Unmarshal(output, &journal)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", jrnl.ErrFailedToReadJournal, err)
+    }
+    entriesByDate := make(map[string][]jrnl.Entry)
+    for _, entry := range journal.Entries {
+        entriesByDate[entry.Date] = append(entriesByDate[entry.Date], entry)
+    }
+    hashes := make(map[string]string)
+    for date, entries := range entriesByDate {
+        contents, err := json.Marshal(entries)
+        if err != nil {
+            return nil, fmt.Errorf("%w%s", jrnl.ErrFailedToReadJournal, err)
+        }
+        hashes[date] = fmt.Sprintf("%x", sha256.Sum256(contents))
+    }
+    return hashes, nil
+}
@@ -0,0 +1,188 @@
+package daemon_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/daemon"
+	"github.com/jm96441n/jrnlSync/jrnl"
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+const watchedJournal = "[2021-11-24 09:00] Standup.\n\n[2021-11-25 07:15] Run.\n"
+
+type fakeWatch struct {
+    changes chan string
+    quiet chan time.Time
+    waits []time.Duration
+}
+
+func (f *fakeWatch) watch(_ context.Context, _ string) (<-chan string, error) {
+    return f.changes, nil
+}
+
+func (f *fakeWatch) after(d time.Duration) <-chan time.Time {
+    f.waits = append(f.waits, d)
+    return f.quiet
+}
+
+type watchRunner struct {
+    args [][]string
+    errs []error
+    done chan struct{}
+}
+
+func (r *watchRunner) run(_ context.Context, args []string) error {
+    r.args = append(r.args, args)
+    var err error
+    if len(r.errs) > 0 {
+        err, r.errs = r.errs[0], r.errs[1:]
+    }
+    r.done <- struct{}{}
+    return err
+}
+
+func newTestWatch(t *testing.T) (*ffcli.Command, string, *fakeWatch, *watchRunner) {
+    t.Helper()
+    journalFile := filepath.Join(t.TempDir(), "journal.txt")
+    err := os.WriteFile(journalFile, []byte(watchedJournal), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    watch := &fakeWatch{changes: make(chan string), quiet: make(chan time.Time)}
+    runner := &watchRunner{done: make(chan struct{}, 10)}
+    c := daemon.NewWatchConfig(jrnl.NewSource(nil), runner.run, bytes.NewBuffer([]byte{}))
+    c.Watch = watch.watch
+    c.After = watch.after
+    c.Signals = make(chan os.Signal, 1)
+    return daemon.NewWatchFlagSet(c), journalFile, watch, runner
+}
+
+func startWatch(ctx context.Context, command *ffcli.Command, args []string) chan error {
+    errs := make(chan error, 1)
+    go func() {
+        errs <- command.ParseAndRun(ctx, args)
+    }()
+    return errs
+}
+
+func waitForRuns(t *testing.T, runner *watchRunner, n int) {
+    t.Helper()
+    for i := 0; i < n; i++ {
+        select {
+        case <-runner.done:
+        case <-time.After(5 * time.Second):
+            t.Fatalf("Expected %d syncs, got %d", n, i)
+        }
+    }
+}
+
+func TestWatchSyncsTheDatesThatChangedOnceWritesSettle(t *testing.T) {
+    command, journalFile, watch, runner := newTestWatch(t)
+    ctx, cancel := context.WithCancel(context.Background())
+    errs := startWatch(ctx, command, []string{"-journal-file", journalFile, "-debounce", "5s", "notion", "-d", "databaseid"})
+
+    watch.changes <- journalFile
+    err := os.WriteFile(journalFile, []byte(watchedJournal+"\n[2021-11-25 12:00] Lunch.\n\n[2021-11-26 08:00] Coffee.\n"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    watch.changes <- journalFile
+    watch.changes <- journalFile
+    watch.quiet <- time.Now()
+    waitForRuns(t, runner, 2)
+    cancel()
+    err = <-errs
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expected := [][]string{
+        {"notion", "-date", "2021-11-25", "-journal-file=" + journalFile, "-d", "databaseid"},
+        {"notion", "-date", "2021-11-26", "-journal-file=" + journalFile, "-d", "databaseid"},
+    }
+    if !reflect.DeepEqual(expected, runner.args) {
+        t.Errorf("Expected syncs %v, got %v", expected, runner.args)
+    }
+    expectedWaits := []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}
+    if !reflect.DeepEqual(expectedWaits, watch.waits) {
+        t.Errorf("Expected each change to restart the wait, got %v", watch.waits)
+    }
+}
+
+func TestWatchTriesADateThatFailedToSyncAgainAfterTheNextChange(t *testing.T) {
+    command, journalFile, watch, runner := newTestWatch(t)
+    runner.errs = []error{errors.New("notion is down")}
+    ctx, cancel := context.WithCancel(context.Background())
+    errs := startWatch(ctx, command, []string{"-journal-file", journalFile, "joplin"})
+
+    watch.changes <- journalFile
+    err := os.WriteFile(journalFile, []byte(watchedJournal+"\n[2021-11-26 08:00] Coffee.\n"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    watch.quiet <- time.Now()
+    waitForRuns(t, runner, 1)
+    // a save that doesn't change any entries
+    watch.changes <- journalFile
+    watch.quiet <- time.Now()
+    waitForRuns(t, runner, 1)
+    watch.changes <- journalFile
+    watch.quiet <- time.Now()
+    cancel()
+    err = <-errs
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    sync := []string{"joplin", "-date", "2021-11-26", "-journal-file=" + journalFile}
+    if !reflect.DeepEqual([][]string{sync, sync}, runner.args) {
+        t.Errorf("Expected the failed date to be synced again and only again, got %v", runner.args)
+    }
+}
+
+func TestWatchReturnsErrForACommandThatCantSyncADate(t *testing.T) {
+    command, journalFile, _, _ := newTestWatch(t)
+    err := command.ParseAndRun(context.Background(), []string{"-journal-file", journalFile, "site"})
+    if !errors.Is(err, daemon.ErrUnsupportedWatchCommand) {
+        t.Errorf("Expected error to be %q, got %q", daemon.ErrUnsupportedWatchCommand, err)
+    }
+}
+
+func TestNotifyWatcherSeesTheJournalBeingSavedOverARename(t *testing.T) {
+    dir := t.TempDir()
+    journalFile := filepath.Join(dir, "journal.txt")
+    err := os.WriteFile(journalFile, []byte(watchedJournal), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    changes, err := daemon.NotifyWatcher(ctx, journalFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tmp := filepath.Join(dir, "journal.txt.tmp")
+    err = os.WriteFile(tmp, []byte(watchedJournal+"\n[2021-11-26 08:00] Coffee.\n"), 0600)
+    if err == nil {
+        err = os.Rename(tmp, journalFile)
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case name := <-changes:
+        if name != journalFile {
+            t.Errorf("Expected a change to %s, got %s", journalFile, name)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("Expected a change to the journal")
+    }
+}
@@ -3,3 +3,11 @@ module github.com/jm96441n/jrnlSync
 go 1.17
 
 require github.com/peterbourgon/ff/v3 v3.1.2
+
+require gopkg.in/yaml.v2 v2.4.0
+
+require github.com/pelletier/go-toml v1.6.0
+
+require github.com/fsnotify/fsnotify v1.6.0
+
+require golang.org/x/sys v0.0.0-20220908164124-27713097b956 // indirect
@@ -0,0 +1,166 @@
+package jrnl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultJournalName = "default"
+
+const (
+    JournalTypeFile = "file"
+    JournalTypeFolder = "folder"
+    JournalTypeEncrypted = "encrypted"
+)
+
+type Config struct {
+    TimeFormat string
+    TagSymbols string
+    Encrypt bool
+    Journals map[string]JournalConfig
+}
+
+type JournalConfig struct {
+    Name string
+    Path string
+    Type string
+    TimeFormat string
+    TagSymbols string
+}
+
+type rawConfig struct {
+    TimeFormat string `yaml:"timeformat"`
+    TagSymbols string `yaml:"tagsymbols"`
+    Encrypt bool `yaml:"encrypt"`
+    Journals map[string]interface{} `yaml:"journals"`
+}
+
+type rawJournalConfig struct {
+    Journal string `yaml:"journal"`
+    TimeFormat string `yaml:"timeformat"`
+    TagSymbols string `yaml:"tagsymbols"`
+    Encrypt *bool `yaml:"encrypt"`
+}
+
+var ErrFailedToReadConfig = errors.New("failed to read jrnl config: ")
+var ErrUnknownJournal = errors.New("journal not found in jrnl config: ")
+
+func DefaultConfigPath() string {
+    configHome := os.Getenv("XDG_CONFIG_HOME")
+    if configHome == "" {
+        configHome = ExpandHome("~/.config")
+    }
+    path := filepath.Join(configHome, "jrnl", "jrnl.yaml")
+    if _, err := os.Stat(path); err != nil {
+        legacy := ExpandHome("~/.jrnl_config")
+        if _, err := os.Stat(legacy); err == nil {
+            return legacy
+        }
+    }
+    return path
+}
+
+func LoadConfig(path string) (Config, error) {
+    contents, err := os.ReadFile(ExpandHome(path))
+    if err != nil {
+        return Config{}, fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+    }
+    raw := rawConfig{}
+    err = yaml.Unmarshal(contents, &raw)
+    if err != nil {
+        return Config{}, fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+    }
+
+    config := Config{
+        TimeFormat: raw.TimeFormat,
+        TagSymbols: raw.TagSymbols,
+        Encrypt: raw.Encrypt,
+        Journals: make(map[string]JournalConfig),
+    }
+    if config.TimeFormat == "" {
+        config.TimeFormat = DefaultTimeFormat
+    }
+    if config.TagSymbols == "" {
+        config.TagSymbols = DefaultTagSymbols
+    }
+    for name, value := range raw.Journals {
+        journal, err := config.newJournalConfig(name, value)
+        if err != nil {
+            return Config{}, err
+        }
+        config.Journals[name] = journal
+    }
+    return config, nil
+}
+
+func (c Config) Journal(name string) (JournalConfig, error) {
+    if name == "" {
+        name = DefaultJournalName
+    }
+    journal, ok := c.Journals[name]
+    if !ok {
+        return JournalConfig{}, fmt.Errorf("%w%q (have %s)", ErrUnknownJournal, name, strings.Join(c.JournalNames(), ", "))
+    }
+    return journal, nil
+}
+
+func (c Config) JournalNames() []string {
+    names := make([]string, 0, len(c.Journals))
+    for name := range c.Journals {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func (c Config) newJournalConfig(name string, value interface{}) (JournalConfig, error) {
+    raw := rawJournalConfig{}
+    switch v := value.(type) {
+    case string:
+        raw.Journal = v
+    default:
+        contents, err := yaml.Marshal(v)
+        if err != nil {
+            return JournalConfig{}, fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+        }
+        err = yaml.Unmarshal(contents, &raw)
+        if err != nil {
+            return JournalConfig{}, fmt.Errorf("%w%s", ErrFailedToReadConfig, err)
+        }
+    }
+
+    journal := JournalConfig{
+        Name: name,
+        Path: ExpandHome(raw.Journal),
+        Type: JournalTypeFile,
+        TimeFormat: c.TimeFormat,
+        TagSymbols: c.TagSymbols,
+    }
+    if journal.Path != "" {
+        journal.Path = filepath.Clean(journal.Path)
+    }
+    if raw.TimeFormat != "" {
+        journal.TimeFormat = raw.TimeFormat
+    }
+    if raw.TagSymbols != "" {
+        journal.TagSymbols = raw.TagSymbols
+    }
+    encrypt := c.Encrypt
+    if raw.Encrypt != nil {
+        encrypt = *raw.Encrypt
+    }
+    info, err := os.Stat(journal.Path)
+    switch {
+    case encrypt:
+        journal.Type = JournalTypeEncrypted
+    case strings.HasSuffix(raw.Journal, "/") || (err == nil && info.IsDir()):
+        journal.Type = JournalTypeFolder
+    }
+    return journal, nil
+}
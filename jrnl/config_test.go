@@ -0,0 +1,134 @@
+package jrnl_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/jrnl"
+)
+
+func writeJrnlConfig(t *testing.T, dir string) string {
+    err := os.MkdirAll(filepath.Join(dir, "folder"), 0700)
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = os.WriteFile(filepath.Join(dir, "personal.txt"), []byte(journalText), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = os.WriteFile(filepath.Join(dir, "work.txt"), []byte("[24 Nov 2021 06:30 PM] Retro. With @team.\n"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    config := fmt.Sprintf(`colors:
+  body: none
+default_hour: 9
+default_minute: 0
+encrypt: false
+journals:
+  default: %[1]s/personal.txt
+  work:
+    journal: %[1]s/work.txt
+    timeformat: '%%d %%b %%Y %%I:%%M %%p'
+    tagsymbols: '@'
+  notes: %[1]s/folder/
+  secret:
+    journal: %[1]s/secret.txt
+    encrypt: true
+tagsymbols: '#@'
+timeformat: '%%Y-%%m-%%d %%H:%%M'
+version: v2.8
+`, dir)
+    path := filepath.Join(dir, "jrnl.yaml")
+    err = os.WriteFile(path, []byte(config), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestLoadConfigDiscoversJournalsAndTheirSettings(t *testing.T) {
+    dir := t.TempDir()
+    config, err := jrnl.LoadConfig(writeJrnlConfig(t, dir))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expected := map[string]jrnl.JournalConfig{
+        "default": {Name: "default", Path: filepath.Join(dir, "personal.txt"), Type: jrnl.JournalTypeFile, TimeFormat: "%Y-%m-%d %H:%M", TagSymbols: "#@"},
+        "work": {Name: "work", Path: filepath.Join(dir, "work.txt"), Type: jrnl.JournalTypeFile, TimeFormat: "%d %b %Y %I:%M %p", TagSymbols: "@"},
+        "notes": {Name: "notes", Path: filepath.Join(dir, "folder"), Type: jrnl.JournalTypeFolder, TimeFormat: "%Y-%m-%d %H:%M", TagSymbols: "#@"},
+        "secret": {Name: "secret", Path: filepath.Join(dir, "secret.txt"), Type: jrnl.JournalTypeEncrypted, TimeFormat: "%Y-%m-%d %H:%M", TagSymbols: "#@"},
+    }
+    if !reflect.DeepEqual(expected, config.Journals) {
+        t.Errorf("expected journals to be %+v, got %+v", expected, config.Journals)
+    }
+
+    defaultJournal, err := config.Journal("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if defaultJournal.Name != jrnl.DefaultJournalName {
+        t.Errorf("expected the default journal when no name is given, got %s", defaultJournal.Name)
+    }
+    _, err = config.Journal("missing")
+    if !errors.Is(err, jrnl.ErrUnknownJournal) {
+        t.Errorf("Expected error to be of type ErrUnknownJournal, got %+v", err)
+    }
+}
+
+func TestLoadConfigReturnsErrWhenTheConfigIsMissing(t *testing.T) {
+    _, err := jrnl.LoadConfig(filepath.Join(t.TempDir(), "jrnl.yaml"))
+    if !errors.Is(err, jrnl.ErrFailedToReadConfig) {
+        t.Errorf("Expected error to be of type ErrFailedToReadConfig, got %+v", err)
+    }
+}
+
+func TestSourceReadsANamedJournalWithItsSettings(t *testing.T) {
+    dir := t.TempDir()
+    commands := &mockCommands{}
+    source := newTestSource(t, commands)
+    source.ConfigFile = writeJrnlConfig(t, dir)
+    source.Journal = "work"
+    journal := readJournal(t, source)
+
+    if len(journal.Entries) != 1 {
+        t.Fatalf("expected 1 entry, got %d", len(journal.Entries))
+    }
+    e := journal.Entries[0]
+    if e.Title != "Retro." || e.Time != "18:30" || !reflect.DeepEqual(e.Tags, []string{"@team"}) {
+        t.Errorf("expected the work journal to be read with its own settings, got %+v", e)
+    }
+    if len(commands.calls) != 0 {
+        t.Errorf("expected jrnl not to be run, got %v", commands.calls)
+    }
+}
+
+func TestSourceRunsJrnlForEncryptedJournals(t *testing.T) {
+    dir := t.TempDir()
+    commands := &mockCommands{output: `{"entries": []}`}
+    source := newTestSource(t, commands)
+    source.ConfigFile = writeJrnlConfig(t, dir)
+    source.Journal = "secret"
+    _, err := source.Output()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(commands.calls, [][]string{{"--config-file", source.ConfigFile, "secret", "--format", "json"}}) {
+        t.Errorf("expected jrnl to be run for the secret journal, got %v", commands.calls)
+    }
+}
+
+func TestSourceReturnsErrForUnknownJournals(t *testing.T) {
+    source := newTestSource(t, &mockCommands{})
+    source.ConfigFile = writeJrnlConfig(t, t.TempDir())
+    source.Journal = "missing"
+    _, err := source.Output()
+    if !errors.Is(err, jrnl.ErrUnknownJournal) {
+        t.Errorf("Expected error to be of type ErrUnknownJournal, got %+v", err)
+    }
+}
@@ -0,0 +1,125 @@
+package jrnl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// jrnl derives the Fernet key for a v2 encrypted journal from the password
+// with this fixed salt, see jrnl/encryption/Jrnlv2Encryption.py.
+var jrnlSalt = []byte("\xf2\xd5q\x0e\xc1\x8d.\xde\xdc\x8e6t\x89\x04\xce\xf8")
+
+const (
+    jrnlKeyIterations = 100000
+    fernetVersion = 0x80
+    fernetHeaderSize = 1 + 8 + aes.BlockSize
+)
+
+var ErrNoJournalPassword = errors.New("the journal is encrypted but no password was given: ")
+var ErrFailedToGetJournalPassword = errors.New("failed to get the journal password: ")
+var ErrFailedToDecryptJournal = errors.New("failed to decrypt the journal, is the password right? ")
+
+func (s *Source) hasPassword() bool {
+    return s.PasswordFile != "" || s.PasswordCommand != "" || (s.PasswordEnv != "" && os.Getenv(s.PasswordEnv) != "")
+}
+
+func (s *Source) password() (string, error) {
+    if s.PasswordEnv != "" {
+        if password := os.Getenv(s.PasswordEnv); password != "" {
+            return password, nil
+        }
+    }
+    if s.PasswordFile != "" {
+        password, err := os.ReadFile(ExpandHome(s.PasswordFile))
+        if err != nil {
+            return "", fmt.Errorf("%w%s", ErrFailedToGetJournalPassword, err)
+        }
+        return strings.TrimRight(string(password), "\r\n"), nil
+    }
+    if s.PasswordCommand != "" {
+        password, err := exec.Command("sh", "-c", s.PasswordCommand).Output()
+        if err != nil {
+            return "", fmt.Errorf("%w%s", ErrFailedToGetJournalPassword, err)
+        }
+        // pass and friends print the password on the first line
+        return strings.SplitN(strings.TrimRight(string(password), "\r\n"), "\n", 2)[0], nil
+    }
+    return "", fmt.Errorf("%wset %s, -password-file or -password-command", ErrNoJournalPassword, s.PasswordEnv)
+}
+
+func isEncrypted(contents []byte) bool {
+    return bytes.HasPrefix(bytes.TrimSpace(contents), []byte("gAAAAA"))
+}
+
+func decrypt(contents []byte, password string) ([]byte, error) {
+    token, err := base64.URLEncoding.DecodeString(string(bytes.TrimSpace(contents)))
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToDecryptJournal, err)
+    }
+    if len(token) < fernetHeaderSize+sha256.Size || token[0] != fernetVersion {
+        return nil, fmt.Errorf("%wnot a jrnl v2 encrypted journal", ErrFailedToDecryptJournal)
+    }
+
+    key := pbkdf2([]byte(password), jrnlSalt, jrnlKeyIterations, 32, sha256.New)
+    signingKey, encryptionKey := key[:16], key[16:]
+    signed, signature := token[:len(token)-sha256.Size], token[len(token)-sha256.Size:]
+    mac := hmac.New(sha256.New, signingKey)
+    mac.Write(signed)
+    if !hmac.Equal(mac.Sum(nil), signature) {
+        return nil, fmt.Errorf("%wthe signature doesn't match", ErrFailedToDecryptJournal)
+    }
+
+    iv, ciphertext := signed[1+8:fernetHeaderSize], signed[fernetHeaderSize:]
+    if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+        return nil, fmt.Errorf("%wthe ciphertext is truncated", ErrFailedToDecryptJournal)
+    }
+    block, err := aes.NewCipher(encryptionKey)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToDecryptJournal, err)
+    }
+    plaintext := make([]byte, len(ciphertext))
+    cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+    padding := int(plaintext[len(plaintext)-1])
+    if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+        return nil, fmt.Errorf("%wbad padding", ErrFailedToDecryptJournal)
+    }
+    return plaintext[:len(plaintext)-padding], nil
+}
+
+// pbkdf2 is PBKDF2 from RFC 8018, small enough that it isn't worth pulling in
+// golang.org/x/crypto for.
+func pbkdf2(password, salt []byte, iterations, keyLen int, h func() hash.Hash) []byte {
+    prf := hmac.New(h, password)
+    key := make([]byte, 0, keyLen)
+    counter := make([]byte, 4)
+    for block := uint32(1); len(key) < keyLen; block++ {
+        binary.BigEndian.PutUint32(counter, block)
+        prf.Reset()
+        prf.Write(salt)
+        prf.Write(counter)
+        u := prf.Sum(nil)
+        t := append([]byte{}, u...)
+        for i := 1; i < iterations; i++ {
+            prf.Reset()
+            prf.Write(u)
+            u = prf.Sum(u[:0])
+            for j := range t {
+                t[j] ^= u[j]
+            }
+        }
+        key = append(key, t...)
+    }
+    return key[:keyLen]
+}
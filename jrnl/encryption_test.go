@@ -0,0 +1,105 @@
+package jrnl_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/jrnl"
+)
+
+// encrypted with jrnl v2 encryption using the password "correct horse"
+const encryptedJournal = "gAAAAABhnmMQAAECAwQFBgcICQoLDA0ODz63RirIrh0-dmR76J74_Ua9UgpxJy0426Ooqxr9fZy2zv3kes007TEUN_ln5tqDV-oEcQc-7fa3cw39mUZgh4Rnu6qskZlwglmqmgWs4C1zKeaQ4IWfhH8J3ZWD9sXZdK7z--D_ljL-Jp0ezmSvr2ixoiGXK6yq3d4GjYERgO1KIjsonfLB2fCUHBKkMu23HfelIVt9B6n-yAyrBwhMT6Y="
+
+func TestSourceDecryptsEncryptedJournals(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "journal.txt")
+    err := os.WriteFile(path, []byte(encryptedJournal), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    passwordFile := filepath.Join(dir, "password")
+    err = os.WriteFile(passwordFile, []byte("correct horse\n"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("JRNLSYNC_TEST_PASSWORD", "correct horse")
+
+    testCases := []struct{
+        name string
+        setPassword func(*jrnl.Source)
+    }{
+        {
+            name: "password from an env var",
+            setPassword: func(s *jrnl.Source) { s.PasswordEnv = "JRNLSYNC_TEST_PASSWORD" },
+        },
+        {
+            name: "password from a file",
+            setPassword: func(s *jrnl.Source) { s.PasswordFile = passwordFile },
+        },
+        {
+            name: "password from a command",
+            setPassword: func(s *jrnl.Source) { s.PasswordCommand = "printf 'correct horse\\nurl: jrnl.sh\\n'" },
+        },
+    }
+
+    for _, testCase := range testCases {
+        commands := &mockCommands{}
+        source := newTestSource(t, commands)
+        source.JournalFile = path
+        testCase.setPassword(source)
+        journal := readJournal(t, source)
+
+        if len(journal.Entries) != 2 || journal.Entries[1].Title != "Dinner" || !journal.Entries[1].Starred {
+            t.Errorf("%s: expected the decrypted entries, got %+v", testCase.name, journal.Entries)
+        }
+        if len(commands.calls) != 0 {
+            t.Errorf("%s: expected jrnl not to be run, got %v", testCase.name, commands.calls)
+        }
+    }
+}
+
+func TestSourceDecryptsEncryptedJournalsFromTheJrnlConfig(t *testing.T) {
+    dir := t.TempDir()
+    err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte(encryptedJournal+"\n"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("JRNLSYNC_TEST_PASSWORD", "correct horse")
+    commands := &mockCommands{}
+    source := newTestSource(t, commands)
+    source.ConfigFile = writeJrnlConfig(t, dir)
+    source.Journal = "secret"
+    source.PasswordEnv = "JRNLSYNC_TEST_PASSWORD"
+    journal := readJournal(t, source)
+
+    if len(journal.Entries) != 2 {
+        t.Errorf("expected 2 entries, got %+v", journal.Entries)
+    }
+    if len(commands.calls) != 0 {
+        t.Errorf("expected jrnl not to be run, got %v", commands.calls)
+    }
+}
+
+func TestSourceReturnsErrWhenTheJournalCantBeDecrypted(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    err := os.WriteFile(path, []byte(encryptedJournal), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("JRNLSYNC_TEST_PASSWORD", "battery staple")
+
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = path
+    _, err = source.Output()
+    if !errors.Is(err, jrnl.ErrNoJournalPassword) {
+        t.Errorf("Expected error to be of type ErrNoJournalPassword, got %+v", err)
+    }
+
+    source.PasswordEnv = "JRNLSYNC_TEST_PASSWORD"
+    _, err = source.Output()
+    if !errors.Is(err, jrnl.ErrFailedToDecryptJournal) {
+        t.Errorf("Expected error to be of type ErrFailedToDecryptJournal, got %+v", err)
+    }
+}
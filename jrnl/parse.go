@@ -0,0 +1,154 @@
+package jrnl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultTimeFormat = "%Y-%m-%d %H:%M"
+const DefaultTagSymbols = "#@"
+
+type Journal struct {
+    Tags map[string]int `json:"tags"`
+    Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+    Title string `json:"title"`
+    Body string `json:"body"`
+    Date string `json:"date"`
+    Time string `json:"time"`
+    Tags []string `json:"tags"`
+    Starred bool `json:"starred"`
+}
+
+type parser struct {
+    layout string
+    tagRegex *regexp.Regexp
+}
+
+var ErrInvalidTagSymbols = errors.New("invalid tagsymbols: ")
+
+var headerRegex = regexp.MustCompile(`^\[([^\]]+)\] ?(.*)$`)
+var sentenceEndRegex = regexp.MustCompile(`[.!?]['"\x{2019}\x{201D}]?\s+|\n`)
+
+func newParser(timeFormat, tagSymbols string) (parser, error) {
+    layout, err := layoutFromStrftime(timeFormat)
+    if err != nil {
+        return parser{}, err
+    }
+    p := parser{layout: layout}
+    if tagSymbols != "" {
+        p.tagRegex, err = regexp.Compile(fmt.Sprintf(`(?:^|\s)([%s][-+*#/\w]+)`, tagSymbolClass(tagSymbols)))
+        if err != nil {
+            return parser{}, fmt.Errorf("%w%q: %s", ErrInvalidTagSymbols, tagSymbols, err)
+        }
+    }
+    return p, nil
+}
+
+// tagSymbolClass escapes each symbol for the bracket expression they go in,
+// QuoteMeta leaves - and ^ which mean a range or a negation there.
+func tagSymbolClass(tagSymbols string) string {
+    var class strings.Builder
+    for _, r := range tagSymbols {
+        if strings.ContainsRune(`\]-^[`, r) {
+            class.WriteRune('\\')
+        }
+        class.WriteRune(r)
+    }
+    return class.String()
+}
+
+func (p parser) parse(text string) []Entry {
+    entries := make([]Entry, 0)
+    var current *time.Time
+    var lines []string
+    flush := func() {
+        if current != nil {
+            entries = append(entries, p.newEntry(*current, strings.Join(lines, "\n")))
+        }
+    }
+
+    for _, line := range strings.Split(text, "\n") {
+        if match := headerRegex.FindStringSubmatch(line); match != nil {
+            date, err := time.ParseInLocation(p.layout, match[1], time.Local)
+            if err == nil {
+                flush()
+                current = &date
+                lines = []string{match[2]}
+                continue
+            }
+        }
+        lines = append(lines, line)
+    }
+    flush()
+    return entries
+}
+
+func (p parser) newEntry(date time.Time, text string) Entry {
+    text = strings.TrimRight(text, " \t\n")
+    starred := false
+    lines := strings.SplitN(text, "\n", 2)
+    if strings.HasSuffix(strings.TrimSpace(lines[0]), "*") {
+        starred = true
+        lines[0] = strings.TrimRight(strings.TrimSpace(lines[0]), " *")
+        text = strings.Join(lines, "\n")
+    }
+
+    title, body := splitTitle(text)
+    return Entry{
+        Title: title,
+        Body: body,
+        Date: date.Format("2006-01-02"),
+        Time: date.Format("15:04"),
+        Tags: p.tags(fmt.Sprintf("%s %s", title, body)),
+        Starred: starred,
+    }
+}
+
+func (p parser) tags(text string) []string {
+    tags := make([]string, 0)
+    if p.tagRegex == nil {
+        return tags
+    }
+    seen := make(map[string]bool)
+    for _, match := range p.tagRegex.FindAllStringSubmatch(text, -1) {
+        tag := strings.ToLower(match[1])
+        if !seen[tag] {
+            seen[tag] = true
+            tags = append(tags, tag)
+        }
+    }
+    sort.Strings(tags)
+    return tags
+}
+
+func splitTitle(text string) (string, string) {
+    text = strings.TrimSpace(text)
+    loc := sentenceEndRegex.FindStringIndex(text)
+    if loc == nil {
+        return text, ""
+    }
+    return strings.TrimSpace(text[:loc[1]]), strings.TrimSpace(text[loc[1]:])
+}
+
+func newJournal(entries []Entry) Journal {
+    sort.SliceStable(entries, func(i, j int) bool {
+        if entries[i].Date != entries[j].Date {
+            return entries[i].Date < entries[j].Date
+        }
+        return entries[i].Time < entries[j].Time
+    })
+    tags := make(map[string]int)
+    for _, e := range entries {
+        for _, tag := range e.Tags {
+            tags[tag]++
+        }
+    }
+    return Journal{Tags: tags, Entries: entries}
+}
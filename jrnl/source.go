@@ -0,0 +1,295 @@
+package jrnl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Outputter is a command whose output can be read as it's written, like
+// exec.Cmd.
+type Outputter interface {
+    StdoutPipe() (io.ReadCloser, error)
+    Start() error
+    Wait() error
+}
+
+type Source struct {
+    Journal string
+    JournalFile string
+    ConfigFile string
+    TimeFormat string
+    TagSymbols string
+    PasswordEnv string
+    PasswordFile string
+    PasswordCommand string
+    From string
+    To string
+    Command func(args ...string) Outputter
+    running Outputter
+}
+
+var ErrFailedToReadJournal = errors.New("failed to read jrnl journal: ")
+
+var folderJournalFileRegex = regexp.MustCompile(`^\d{4}/\d{2}/\d{2}\.txt$`)
+
+func NewSource(command func(args ...string) Outputter) *Source {
+    return &Source{
+        ConfigFile: DefaultConfigPath(),
+        PasswordEnv: "JRNL_PASSWORD",
+        Command: command,
+    }
+}
+
+func ExecCommand(args ...string) Outputter {
+    cmd := &jrnlCommand{Cmd: exec.Command("jrnl", args...)}
+    cmd.Stderr = &cmd.stderr
+    return cmd
+}
+
+type jrnlCommand struct {
+    *exec.Cmd
+    stderr bytes.Buffer
+}
+
+// Wait adds what jrnl printed to stderr to the error, that's where it says why
+// it failed.
+func (c *jrnlCommand) Wait() error {
+    err := c.Cmd.Wait()
+    if err != nil && c.stderr.Len() > 0 {
+        return fmt.Errorf("%w: %s", err, strings.TrimSpace(c.stderr.String()))
+    }
+    return err
+}
+
+func (s *Source) RegisterFlags(fs *flag.FlagSet) {
+    fs.StringVar(&s.Journal, "journal", s.Journal, "The name of the journal in your jrnl config to read, defaults to jrnl's default journal")
+    fs.StringVar(&s.JournalFile, "journal-file", s.JournalFile, "Read entries straight from this jrnl journal file or folder instead of running jrnl")
+    fs.StringVar(&s.ConfigFile, "jrnl-config", s.ConfigFile, "The jrnl config file to find journals and their settings in")
+    fs.StringVar(&s.TimeFormat, "timeformat", s.TimeFormat, "The timeformat of the journal, defaults to the one in your jrnl config")
+    fs.StringVar(&s.TagSymbols, "tagsymbols", s.TagSymbols, "The tagsymbols of the journal, defaults to the ones in your jrnl config")
+    fs.StringVar(&s.PasswordEnv, "password-env", s.PasswordEnv, "The environment variable holding the password of an encrypted journal")
+    fs.StringVar(&s.PasswordFile, "password-file", s.PasswordFile, "A file holding the password of an encrypted journal")
+    fs.StringVar(&s.PasswordCommand, "password-command", s.PasswordCommand, "A command that prints the password of an encrypted journal, e.g. 'pass show jrnl'")
+}
+
+func (s *Source) SetDateRange(from, to string) {
+    s.From, s.To = from, to
+}
+
+// StdoutPipe, Start and Wait read the journal's json export the way exec.Cmd
+// runs a command, so it can be decoded while jrnl is still writing it. Journals
+// read straight from their files are in memory already.
+func (s *Source) StdoutPipe() (io.ReadCloser, error) {
+    s.running = nil
+    settings, err := s.Settings()
+    if err != nil {
+        return nil, err
+    }
+    if settings.Path == "" || (settings.Type == JournalTypeEncrypted && !s.hasPassword()) {
+        s.running = s.Command(s.commandArgs()...)
+        return s.running.StdoutPipe()
+    }
+    journal, err := s.read(settings)
+    if err != nil {
+        return nil, err
+    }
+    output, err := json.Marshal(journal)
+    if err != nil {
+        return nil, err
+    }
+    return io.NopCloser(bytes.NewReader(output)), nil
+}
+
+func (s *Source) Start() error {
+    if s.running == nil {
+        return nil
+    }
+    return s.running.Start()
+}
+
+func (s *Source) Wait() error {
+    if s.running == nil {
+        return nil
+    }
+    err := s.running.Wait()
+    s.running = nil
+    return err
+}
+
+func (s *Source) Output() ([]byte, error) {
+    pipe, err := s.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+    err = s.Start()
+    if err != nil {
+        return nil, err
+    }
+    output, err := io.ReadAll(pipe)
+    waitErr := s.Wait()
+    if waitErr != nil {
+        return nil, waitErr
+    }
+    return output, err
+}
+
+func (s *Source) Settings() (JournalConfig, error) {
+    settings := JournalConfig{
+        Name: s.Journal,
+        Path: ExpandHome(s.JournalFile),
+        Type: JournalTypeFile,
+        TimeFormat: DefaultTimeFormat,
+        TagSymbols: DefaultTagSymbols,
+    }
+    if s.Journal != "" || s.JournalFile != "" {
+        config, err := LoadConfig(s.ConfigFile)
+        switch {
+        case err == nil && s.Journal != "":
+            journal, err := config.Journal(s.Journal)
+            if err != nil {
+                return settings, err
+            }
+            if s.JournalFile == "" {
+                settings = journal
+            }
+            settings.TimeFormat, settings.TagSymbols = journal.TimeFormat, journal.TagSymbols
+        case err == nil:
+            settings.TimeFormat, settings.TagSymbols = config.TimeFormat, config.TagSymbols
+        case s.Journal != "":
+            return settings, err
+        }
+    }
+    if s.TimeFormat != "" {
+        settings.TimeFormat = s.TimeFormat
+    }
+    if s.TagSymbols != "" {
+        settings.TagSymbols = s.TagSymbols
+    }
+    if info, err := os.Stat(settings.Path); settings.Type == JournalTypeFile && err == nil && info.IsDir() {
+        settings.Type = JournalTypeFolder
+    }
+    return settings, nil
+}
+
+// ImportArgs are the jrnl arguments that import entries into the journal the
+// source reads, rather than jrnl's default journal.
+func (s *Source) ImportArgs() []string {
+    return append(s.journalArgs(), "--import")
+}
+
+func (s *Source) journalArgs() []string {
+    args := make([]string, 0)
+    if s.ConfigFile != "" && s.ConfigFile != DefaultConfigPath() {
+        args = append(args, "--config-file", s.ConfigFile)
+    }
+    if s.Journal != "" {
+        args = append(args, s.Journal)
+    }
+    return args
+}
+
+func (s *Source) commandArgs() []string {
+    args := s.journalArgs()
+    if s.From != "" {
+        args = append(args, "-from", s.From)
+    }
+    if s.To != "" {
+        args = append(args, "-to", s.To)
+    }
+    return append(args, "--format", "json")
+}
+
+func (s *Source) read(settings JournalConfig) (Journal, error) {
+    p, err := newParser(settings.TimeFormat, settings.TagSymbols)
+    if err != nil {
+        return Journal{}, err
+    }
+    path := settings.Path
+    info, err := os.Stat(path)
+    if err != nil {
+        return Journal{}, fmt.Errorf("%w%s", ErrFailedToReadJournal, err)
+    }
+    if !info.IsDir() {
+        text, err := os.ReadFile(path)
+        if err != nil {
+            return Journal{}, fmt.Errorf("%w%s", ErrFailedToReadJournal, err)
+        }
+        if settings.Type == JournalTypeEncrypted || isEncrypted(text) {
+            password, err := s.password()
+            if err != nil {
+                return Journal{}, err
+            }
+            text, err = decrypt(text, password)
+            if err != nil {
+                return Journal{}, err
+            }
+        }
+        return newJournal(s.inDateRange(p.parse(string(text)))), nil
+    }
+
+    files := make([]string, 0)
+    err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        rel, err := filepath.Rel(path, file)
+        if err != nil {
+            return err
+        }
+        rel = filepath.ToSlash(rel)
+        if !d.IsDir() && folderJournalFileRegex.MatchString(rel) && s.wantsDate(strings.ReplaceAll(strings.TrimSuffix(rel, ".txt"), "/", "-")) {
+            files = append(files, file)
+        }
+        return nil
+    })
+    if err != nil {
+        return Journal{}, fmt.Errorf("%w%s", ErrFailedToReadJournal, err)
+    }
+    sort.Strings(files)
+
+    entries := make([]Entry, 0)
+    for _, file := range files {
+        text, err := os.ReadFile(file)
+        if err != nil {
+            return Journal{}, fmt.Errorf("%w%s", ErrFailedToReadJournal, err)
+        }
+        entries = append(entries, p.parse(string(text))...)
+    }
+    return newJournal(s.inDateRange(entries)), nil
+}
+
+func (s *Source) inDateRange(entries []Entry) []Entry {
+    filtered := make([]Entry, 0, len(entries))
+    for _, e := range entries {
+        if s.wantsDate(e.Date) {
+            filtered = append(filtered, e)
+        }
+    }
+    return filtered
+}
+
+func (s *Source) wantsDate(date string) bool {
+    return (s.From == "" || date >= s.From) && (s.To == "" || date <= s.To)
+}
+
+func ExpandHome(path string) string {
+    if path != "~" && !strings.HasPrefix(path, "~/") {
+        return path
+    }
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return path
+    }
+    return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,300 @@
+package jrnl_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/jrnl"
+)
+
+const journalText = `[2021-11-24 09:00] Standup with @Work folks. Talked about the release.
+Second line of the body.
+
+[2021-11-24 18:30] Dinner *
+Made soup with #family.
+
+[2021-11-25 07:15] Run
+`
+
+func TestSourceReadsAJournalFile(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    err := os.WriteFile(path, []byte(journalText), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = path
+    journal := readJournal(t, source)
+
+    expected := []jrnl.Entry{
+        {
+            Title: "Standup with @Work folks.",
+            Body: "Talked about the release.\nSecond line of the body.",
+            Date: "2021-11-24",
+            Time: "09:00",
+            Tags: []string{"@work"},
+        },
+        {
+            Title: "Dinner",
+            Body: "Made soup with #family.",
+            Date: "2021-11-24",
+            Time: "18:30",
+            Tags: []string{"#family"},
+            Starred: true,
+        },
+        {
+            Title: "Run",
+            Body: "",
+            Date: "2021-11-25",
+            Time: "07:15",
+            Tags: []string{},
+        },
+    }
+    if !reflect.DeepEqual(expected, journal.Entries) {
+        t.Errorf("expected entries to be %+v, got %+v", expected, journal.Entries)
+    }
+    expectedTags := map[string]int{"@work": 1, "#family": 1}
+    if !reflect.DeepEqual(expectedTags, journal.Tags) {
+        t.Errorf("expected tags to be %+v, got %+v", expectedTags, journal.Tags)
+    }
+}
+
+func TestSourceHonorsTimeFormatAndTagSymbols(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    text := "[24 Nov 2021 06:30 PM] Dinner. With @home and #notatag.\n\n[not a date] still the body\n"
+    err := os.WriteFile(path, []byte(text), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = path
+    source.TimeFormat = "%d %b %Y %I:%M %p"
+    source.TagSymbols = "@"
+    journal := readJournal(t, source)
+
+    if len(journal.Entries) != 1 {
+        t.Fatalf("expected 1 entry, got %d", len(journal.Entries))
+    }
+    e := journal.Entries[0]
+    if e.Date != "2021-11-24" || e.Time != "18:30" {
+        t.Errorf("expected entry to be on 2021-11-24 at 18:30, got %s %s", e.Date, e.Time)
+    }
+    if e.Body != "With @home and #notatag.\n\n[not a date] still the body" {
+        t.Errorf("expected lines that aren't entry headers to stay in the body, got %q", e.Body)
+    }
+    if !reflect.DeepEqual(e.Tags, []string{"@home"}) {
+        t.Errorf("expected only @ tags, got %v", e.Tags)
+    }
+}
+
+func TestSourceReadsTagSymbolsThatAreSpecialInARegex(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    text := "[2021-11-24 18:30] Dinner. With @home, -chores, ^up, \\back and #family.\n"
+    err := os.WriteFile(path, []byte(text), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = path
+    source.TagSymbols = "@-#^\\"
+    journal := readJournal(t, source)
+
+    if len(journal.Entries) != 1 {
+        t.Fatalf("expected 1 entry, got %d", len(journal.Entries))
+    }
+    expected := []string{"#family", "-chores", "@home", "\\back", "^up"}
+    if !reflect.DeepEqual(journal.Entries[0].Tags, expected) {
+        t.Errorf("expected tags %v, got %v", expected, journal.Entries[0].Tags)
+    }
+}
+
+func TestSourceReadsAFolderJournal(t *testing.T) {
+    dir := t.TempDir()
+    files := map[string]string{
+        "2021/11/25.txt": "[2021-11-25 07:15] Run\n",
+        "2021/11/24.txt": "[2021-11-24 09:00] Standup.\n\n[2021-11-24 18:30] Dinner.\n",
+        "notes.txt": "[2021-11-01 09:00] Not part of the journal.\n",
+    }
+    for name, text := range files {
+        path := filepath.Join(dir, name)
+        err := os.MkdirAll(filepath.Dir(path), 0700)
+        if err != nil {
+            t.Fatal(err)
+        }
+        err = os.WriteFile(path, []byte(text), 0600)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = dir
+    journal := readJournal(t, source)
+
+    titles := []string{}
+    for _, e := range journal.Entries {
+        titles = append(titles, e.Title)
+    }
+    expected := []string{"Standup.", "Dinner.", "Run"}
+    if !reflect.DeepEqual(expected, titles) {
+        t.Errorf("expected entries %v, got %v", expected, titles)
+    }
+}
+
+func TestSourceRunsJrnlWhenNoJournalFileIsSet(t *testing.T) {
+    commands := &mockCommands{output: `{"entries": []}`}
+    source := newTestSource(t, commands)
+    output, err := source.Output()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(output) != `{"entries": []}` {
+        t.Errorf("expected the jrnl command output, got %q", output)
+    }
+    if !reflect.DeepEqual(commands.calls, [][]string{{"--config-file", source.ConfigFile, "--format", "json"}}) {
+        t.Errorf("expected jrnl to be run with the config file and --format json, got %v", commands.calls)
+    }
+}
+
+func TestExecCommandReturnsWhatJrnlPrintedToStderr(t *testing.T) {
+    dir := t.TempDir()
+    err := os.WriteFile(filepath.Join(dir, "jrnl"), []byte("#!/bin/sh\necho 'Journal \"missing\" not found' >&2\nexit 1\n"), 0700)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("PATH", dir)
+    source := jrnl.NewSource(jrnl.ExecCommand)
+    source.ConfigFile = filepath.Join(dir, "missing.yaml")
+    _, err = source.Output()
+    if err == nil || !strings.Contains(err.Error(), `Journal "missing" not found`) {
+        t.Errorf("expected jrnl's error to be returned, got %v", err)
+    }
+}
+
+func TestSourceOnlyReturnsEntriesInTheDateRange(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    err := os.WriteFile(path, []byte(journalText), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    commands := &mockCommands{output: `{"entries": []}`}
+    source := newTestSource(t, commands)
+    source.SetDateRange("2021-11-25", "2021-11-25")
+    _, err = source.Output()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expectedArgs := [][]string{{"--config-file", source.ConfigFile, "-from", "2021-11-25", "-to", "2021-11-25", "--format", "json"}}
+    if !reflect.DeepEqual(commands.calls, expectedArgs) {
+        t.Errorf("expected jrnl to be run with %v, got %v", expectedArgs, commands.calls)
+    }
+
+    source.JournalFile = path
+    journal := readJournal(t, source)
+    if len(journal.Entries) != 1 || journal.Entries[0].Title != "Run" {
+        t.Errorf("expected only the entry on 2021-11-25, got %+v", journal.Entries)
+    }
+    if len(journal.Tags) != 0 {
+        t.Errorf("expected only tags from entries in the range, got %+v", journal.Tags)
+    }
+}
+
+func TestSourceImportsIntoTheJournalItReads(t *testing.T) {
+    testCases := []struct{
+        name string
+        journal string
+        configFile string
+        expectedArgs []string
+    }{
+        {name: "the default journal", configFile: jrnl.DefaultConfigPath(), expectedArgs: []string{"--import"}},
+        {name: "a named journal", journal: "work", configFile: jrnl.DefaultConfigPath(), expectedArgs: []string{"work", "--import"}},
+        {name: "another jrnl config", journal: "work", configFile: "/home/me/jrnl.yaml", expectedArgs: []string{"--config-file", "/home/me/jrnl.yaml", "work", "--import"}},
+    }
+    for _, testCase := range testCases {
+        source := jrnl.NewSource((&mockCommands{}).command)
+        source.Journal = testCase.journal
+        source.ConfigFile = testCase.configFile
+        if !reflect.DeepEqual(testCase.expectedArgs, source.ImportArgs()) {
+            t.Errorf("%s: expected jrnl to import with %v, got %v", testCase.name, testCase.expectedArgs, source.ImportArgs())
+        }
+    }
+}
+
+func TestSourceReturnsErrForUnsupportedTimeFormats(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "journal.txt")
+    err := os.WriteFile(path, []byte(journalText), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = path
+    source.TimeFormat = "%Y-%m-%d %Q"
+    _, err = source.Output()
+    if !errors.Is(err, jrnl.ErrUnsupportedTimeFormat) {
+        t.Errorf("Expected error to be of type ErrUnsupportedTimeFormat, got %+v", err)
+    }
+}
+
+func TestSourceReturnsErrWhenTheJournalFileIsMissing(t *testing.T) {
+    source := newTestSource(t, &mockCommands{})
+    source.JournalFile = filepath.Join(t.TempDir(), "missing.txt")
+    _, err := source.Output()
+    if !errors.Is(err, jrnl.ErrFailedToReadJournal) {
+        t.Errorf("Expected error to be of type ErrFailedToReadJournal, got %+v", err)
+    }
+}
+
+func readJournal(t *testing.T, source *jrnl.Source) jrnl.Journal {
+    output, err := source.Output()
+    if err != nil {
+        t.Fatal(err)
+    }
+    journal := jrnl.Journal{}
+    err = json.Unmarshal(output, &journal)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return journal
+}
+
+func newTestSource(t *testing.T, commands *mockCommands) *jrnl.Source {
+    source := jrnl.NewSource(commands.command)
+    source.ConfigFile = filepath.Join(t.TempDir(), "missing.yaml")
+    source.PasswordEnv = ""
+    return source
+}
+
+type mockCommands struct {
+    output string
+    calls [][]string
+}
+
+func (m *mockCommands) command(args ...string) jrnl.Outputter {
+    m.calls = append(m.calls, args)
+    return mockCommand{output: m.output}
+}
+
+type mockCommand struct {
+    output string
+}
+
+func (m mockCommand) StdoutPipe() (io.ReadCloser, error) {
+    return io.NopCloser(strings.NewReader(m.output)), nil
+}
+
+func (m mockCommand) Start() error {
+    return nil
+}
+
+func (m mockCommand) Wait() error {
+    return nil
+}
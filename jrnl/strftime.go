@@ -0,0 +1,54 @@
+package jrnl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnsupportedTimeFormat = errors.New("unsupported directive in timeformat: ")
+
+var strftimeToLayout = map[byte]string{
+    'Y': "2006",
+    'y': "06",
+    'm': "01",
+    'd': "02",
+    'e': "_2",
+    'H': "15",
+    'I': "03",
+    'M': "04",
+    'S': "05",
+    'p': "PM",
+    'b': "Jan",
+    'h': "Jan",
+    'B': "January",
+    'a': "Mon",
+    'A': "Monday",
+    'j': "002",
+    'Z': "MST",
+    'z': "-0700",
+    'f': "000000",
+    '%': "%",
+}
+
+// layoutFromStrftime converts a python strftime format, which is what jrnl's
+// timeformat setting uses, into a go time layout.
+func layoutFromStrftime(format string) (string, error) {
+    var layout strings.Builder
+    for i := 0; i < len(format); i++ {
+        if format[i] != '%' {
+            layout.WriteByte(format[i])
+            continue
+        }
+        if i+1 >= len(format) {
+            return "", fmt.Errorf("%w%q", ErrUnsupportedTimeFormat, format)
+        }
+        i++
+        directive, ok := strftimeToLayout[format[i]]
+        if !ok {
+            return "", fmt.Errorf("%w%%%c", ErrUnsupportedTimeFormat, format[i])
+        }
+        layout.WriteString(directive)
+    }
+    return layout.String(), nil
+}
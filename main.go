@@ -9,6 +9,10 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/jm96441n/jrnlSync/config"
+	"github.com/jm96441n/jrnlSync/daemon"
+	"github.com/jm96441n/jrnlSync/jrnl"
+	"github.com/jm96441n/jrnlSync/profile"
 	"github.com/jm96441n/jrnlSync/setup"
 	"github.com/jm96441n/jrnlSync/sync"
 	"github.com/peterbourgon/ff/v3/ffcli"
@@ -18,12 +22,38 @@ var Version string
 
 func main() {
     rootFlagSet := flag.NewFlagSet("jrnlSync", flag.ExitOnError)
+    configFile := rootFlagSet.String("config", config.DefaultPath(), "A toml, yaml or json file to read flags from")
 
     httpClient := &http.Client{}
-    jrnlCmd := exec.Command("jrnl", "--format", "json")
+    jrnlSource := jrnl.NewSource(jrnl.ExecCommand)
     entryDate := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
-    notionSyncCommand := sync.NewNotionSyncFlagSet(httpClient, jrnlCmd, entryDate)
+    notionSyncCommand := sync.NewNotionSyncFlagSet(httpClient, jrnlSource, entryDate)
+    joplinSyncCommand := sync.NewJoplinSyncFlagSet(httpClient, jrnlSource, entryDate)
+    siteCommand := sync.NewSiteFlagSet(jrnlSource)
+    timelineCommand := sync.NewTimelineSyncFlagSet(jrnlSource)
+    jrnlImporter := sync.CommandImporter{Name: "jrnl"}
+    pullCommand := sync.NewNotionPullFlagSet(httpClient, jrnlSource, jrnlImporter)
+    restoreCommand := sync.NewRestoreFlagSet(httpClient)
+    verifyCommand := sync.NewNotionVerifyFlagSet(httpClient, jrnlSource, entryDate)
+    multiSyncCommand := sync.NewMultiSyncFlagSet(map[string]func(journal string) *ffcli.Command{
+        "notion": func(journal string) *ffcli.Command {
+            return sync.NewNotionSyncFlagSet(httpClient, newJournalSource(journal), entryDate)
+        },
+        "joplin": func(journal string) *ffcli.Command {
+            return sync.NewJoplinSyncFlagSet(httpClient, newJournalSource(journal), entryDate)
+        },
+        "site": func(journal string) *ffcli.Command {
+            return sync.NewSiteFlagSet(newJournalSource(journal))
+        },
+        "timeline": func(journal string) *ffcli.Command {
+            return sync.NewTimelineSyncFlagSet(newJournalSource(journal))
+        },
+    })
 
+    executable, err := os.Executable()
+    if err != nil {
+        executable = "jrnlSync"
+    }
     cronTmpFile, err := os.CreateTemp("", "jrnlSync")
     if err != nil {
         log.Fatal(err)
@@ -35,20 +65,37 @@ func main() {
     )
 
     saveCronCmd := exec.Command("crontab", cronTmpFile.Name())
-    cron := setup.NewCron(cronTmpFile, getCurrentCronttabCmd, saveCronCmd)
+    cron := setup.NewCron(cronTmpFile, executable, configFile, getCurrentCronttabCmd, saveCronCmd)
+
+    schedulers := map[string]setup.Scheduler{
+        setup.SchedulerCron: cron,
+        setup.SchedulerSystemd: setup.NewSystemd(setup.DefaultSystemdDir(), executable, configFile, setup.Systemctl),
+        setup.SchedulerLaunchd: setup.NewLaunchd(setup.DefaultLaunchAgentsDir(), executable, configFile, setup.DefaultLaunchdLogFile(), setup.LaunchdEnvironment(), setup.Launchctl),
+    }
 
-    setupCommand := setup.NewSetupFlagSet(cron)
+    profilesFile := profile.DefaultPath()
+    setupCommand := setup.NewSetupFlagSet(schedulers, profile.NewStore(profilesFile), config.NewFile(configFile))
+    unscheduleCommand := setup.NewUnscheduleFlagSet(schedulers, []*string{configFile, &profilesFile})
+    daemonCommand := daemon.NewDaemonFlagSet(daemon.NewConfig(daemon.ExecRunner(executable, configFile, os.Stdout), os.Stdout))
+    watchCommand := daemon.NewWatchFlagSet(daemon.NewWatchConfig(jrnl.NewSource(jrnl.ExecCommand), daemon.ExecRunner(executable, configFile, os.Stdout), os.Stdout))
 
     rootCommand := &ffcli.Command{
         ShortUsage: "jrnlSync [flags] <subcommand>",
         FlagSet: rootFlagSet,
-        Subcommands: []*ffcli.Command{setupCommand, notionSyncCommand},
+        Subcommands: []*ffcli.Command{setupCommand, unscheduleCommand, notionSyncCommand, joplinSyncCommand, siteCommand, timelineCommand, pullCommand, restoreCommand, verifyCommand, multiSyncCommand, daemonCommand, watchCommand},
         Exec: func(_ context.Context, args []string) error {
             return flag.ErrHelp
         },
     }
+    config.Apply(rootCommand, configFile)
 
     if err := rootCommand.ParseAndRun(context.Background(), os.Args[1:]); err != nil {
         log.Fatal(err)
     }
 }
+
+func newJournalSource(journal string) *jrnl.Source {
+    source := jrnl.NewSource(jrnl.ExecCommand)
+    source.Journal = journal
+    return source
+}
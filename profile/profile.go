@@ -0,0 +1,146 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultName = "default"
+
+type Profile struct {
+    NotionDBID string `yaml:"notion_database_id,omitempty"`
+    NotionKey string `yaml:"notion_key,omitempty"`
+    NotionKeyEnv string `yaml:"notion_key_env,omitempty"`
+    NotionKeyCommand string `yaml:"notion_key_command,omitempty"`
+}
+
+type Store struct {
+    Path string
+}
+
+type profilesFile struct {
+    Profiles map[string]Profile `yaml:"profiles"`
+}
+
+var ErrFailedToReadProfiles = errors.New("failed to read the profiles file: ")
+var ErrInsecureProfiles = errors.New("the profiles file can be read by other users, run chmod 600 on it: ")
+var ErrUnknownProfile = errors.New("profile not found: ")
+var ErrFailedToSaveProfile = errors.New("failed to save the profile: ")
+var ErrFailedToGetNotionKey = errors.New("failed to get the notion key: ")
+
+func NewStore(path string) Store {
+    return Store{Path: path}
+}
+
+func DefaultPath() string {
+    configHome := os.Getenv("XDG_CONFIG_HOME")
+    if configHome == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return filepath.Join(".config", "jrnlSync", "profiles.yaml")
+        }
+        configHome = filepath.Join(home, ".config")
+    }
+    return filepath.Join(configHome, "jrnlSync", "profiles.yaml")
+}
+
+func (s Store) Load(name string) (Profile, error) {
+    profiles, err := s.read()
+    if err != nil {
+        return Profile{}, err
+    }
+    p, ok := profiles.Profiles[name]
+    if !ok {
+        return Profile{}, fmt.Errorf("%w%q in %s", ErrUnknownProfile, name, s.Path)
+    }
+    return p, nil
+}
+
+func (s Store) Save(name string, p Profile) error {
+    profiles := profilesFile{}
+    if _, err := os.Stat(s.Path); !os.IsNotExist(err) {
+        profiles, err = s.read()
+        if err != nil {
+            return err
+        }
+    }
+    if profiles.Profiles == nil {
+        profiles.Profiles = make(map[string]Profile)
+    }
+    profiles.Profiles[name] = p
+    contents, err := yaml.Marshal(profiles)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToSaveProfile, err)
+    }
+
+    err = os.MkdirAll(filepath.Dir(s.Path), 0700)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToSaveProfile, err)
+    }
+    // written next to the real file and renamed over it so the key is never
+    // in a file anyone else can read, not even for a moment
+    tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".profiles-*.yaml")
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToSaveProfile, err)
+    }
+    defer os.Remove(tmp.Name())
+    _, err = tmp.Write(contents)
+    if err == nil {
+        err = tmp.Close()
+    }
+    if err == nil {
+        err = os.Chmod(tmp.Name(), 0600)
+    }
+    if err == nil {
+        err = os.Rename(tmp.Name(), s.Path)
+    }
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToSaveProfile, err)
+    }
+    return nil
+}
+
+func (s Store) read() (profilesFile, error) {
+    info, err := os.Stat(s.Path)
+    if err != nil {
+        return profilesFile{}, fmt.Errorf("%w%s", ErrFailedToReadProfiles, err)
+    }
+    if info.Mode().Perm()&0077 != 0 {
+        return profilesFile{}, fmt.Errorf("%w%s", ErrInsecureProfiles, s.Path)
+    }
+    contents, err := os.ReadFile(s.Path)
+    if err != nil {
+        return profilesFile{}, fmt.Errorf("%w%s", ErrFailedToReadProfiles, err)
+    }
+    profiles := profilesFile{}
+    err = yaml.UnmarshalStrict(contents, &profiles)
+    if err != nil {
+        return profilesFile{}, fmt.Errorf("%w%s", ErrFailedToReadProfiles, err)
+    }
+    return profiles, nil
+}
+
+func (p Profile) Key() (string, error) {
+    if p.NotionKeyEnv != "" {
+        if key := os.Getenv(p.NotionKeyEnv); key != "" {
+            return key, nil
+        }
+    }
+    if p.NotionKeyCommand != "" {
+        key, err := exec.Command("sh", "-c", p.NotionKeyCommand).Output()
+        if err != nil {
+            return "", fmt.Errorf("%w%s", ErrFailedToGetNotionKey, err)
+        }
+        return strings.SplitN(strings.TrimRight(string(key), "\r\n"), "\n", 2)[0], nil
+    }
+    if p.NotionKey == "" {
+        return "", fmt.Errorf("%wthe profile has no notion_key, notion_key_env or notion_key_command", ErrFailedToGetNotionKey)
+    }
+    return p.NotionKey, nil
+}
@@ -0,0 +1,108 @@
+package profile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/profile"
+)
+
+func TestSaveWritesProfilesOnlyTheUserCanRead(t *testing.T) {
+    store := profile.NewStore(filepath.Join(t.TempDir(), "jrnlSync", "profiles.yaml"))
+    work := profile.Profile{NotionDBID: "workdb", NotionKey: "secret_work"}
+    personal := profile.Profile{NotionDBID: "personaldb", NotionKeyCommand: "pass show notion"}
+    err := store.Save("work", work)
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = store.Save("personal", personal)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    info, err := os.Stat(store.Path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if info.Mode().Perm() != 0600 {
+        t.Errorf("Expected the profiles file to be 0600, got %o", info.Mode().Perm())
+    }
+    for name, expected := range map[string]profile.Profile{"work": work, "personal": personal} {
+        loaded, err := store.Load(name)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if loaded != expected {
+            t.Errorf("Expected the %s profile to be %+v, got %+v", name, expected, loaded)
+        }
+    }
+}
+
+func TestLoadReturnsErrWhenOthersCanReadTheProfiles(t *testing.T) {
+    store := profile.NewStore(filepath.Join(t.TempDir(), "profiles.yaml"))
+    err := store.Save("work", profile.Profile{NotionKey: "secret_work"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = os.Chmod(store.Path, 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, err = store.Load("work")
+    if !errors.Is(err, profile.ErrInsecureProfiles) {
+        t.Errorf("Expected error to be of type ErrInsecureProfiles, got %+v", err)
+    }
+}
+
+func TestLoadReturnsErrForAnUnknownProfile(t *testing.T) {
+    store := profile.NewStore(filepath.Join(t.TempDir(), "profiles.yaml"))
+    err := store.Save("work", profile.Profile{NotionKey: "secret_work"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, err = store.Load("personal")
+    if !errors.Is(err, profile.ErrUnknownProfile) {
+        t.Errorf("Expected error to be of type ErrUnknownProfile, got %+v", err)
+    }
+}
+
+func TestKeyComesFromTheEnvACommandOrTheProfile(t *testing.T) {
+    t.Setenv("JRNLSYNC_TEST_NOTION_KEY", "secret_from_env")
+    testCases := []struct{
+        name string
+        p profile.Profile
+        expected string
+    }{
+        {
+            name: "from an env var",
+            p: profile.Profile{NotionKeyEnv: "JRNLSYNC_TEST_NOTION_KEY", NotionKey: "secret_saved"},
+            expected: "secret_from_env",
+        },
+        {
+            name: "from a command",
+            p: profile.Profile{NotionKeyCommand: "printf 'secret_from_command\\nuser: me\\n'"},
+            expected: "secret_from_command",
+        },
+        {
+            name: "saved in the profile",
+            p: profile.Profile{NotionKeyEnv: "JRNLSYNC_TEST_UNSET", NotionKey: "secret_saved"},
+            expected: "secret_saved",
+        },
+    }
+    for _, testCase := range testCases {
+        key, err := testCase.p.Key()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if key != testCase.expected {
+            t.Errorf("%s: expected the key to be %q, got %q", testCase.name, testCase.expected, key)
+        }
+    }
+
+    _, err := profile.Profile{}.Key()
+    if !errors.Is(err, profile.ErrFailedToGetNotionKey) {
+        t.Errorf("Expected error to be of type ErrFailedToGetNotionKey, got %+v", err)
+    }
+}
@@ -5,14 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os/exec"
+	"regexp"
+	"strings"
 )
 
 type Cron struct {
     tmpFile fileWriterSyncer
+    executable string
+    configFile *string
     currentCronCmd commandOutputter
     saveCronCmd commandRunner
 }
 
+const (
+    cronBlockBegin = "# BEGIN jrnlSync"
+    cronBlockEnd = "# END jrnlSync"
+)
+
+// lines setup wrote before it kept its cron task in a block
+var legacyCronLineRegex = regexp.MustCompile(`^(\S+\s+){5}jrnlSync notion( -profile \S+)? > ~/\.jrnlSyncLogs\.txt 2>&1$`)
+
 type fileWriterSyncer interface {
     Sync() error
     Name() string
@@ -28,32 +41,88 @@ type commandRunner interface {
 }
 
 var ErrFailedToGetCurrentCron = errors.New("failed to get current cron list")
-var ErrFailedToWriteNewCronTaskToBuffer = errors.New("failed to write the new cron task to the buffer")
+var ErrUnterminatedCronBlock = errors.New("the crontab has a '# BEGIN jrnlSync' line without a matching '# END jrnlSync', fix it with crontab -e")
 var ErrFailedToWriteNewCronFile = errors.New("failed to write the new cron tmp file")
 var ErrFailedToSyncFile = errors.New("failed to sync writes to the new cron file")
 var ErrFailedToCloseFile = errors.New("failed to close the cron tmp file")
 var ErrFailedToSetNewCron = errors.New("failed to set new cron")
+var ErrFailedToRemoveFile = errors.New("failed to remove file")
 
-func NewCron(tmpFile fileWriterSyncer, currentCronCmd commandOutputter, saveCronCmd commandRunner) Cron {
+// NewCron takes the config file as a pointer so its path can come from a flag
+// that hasn't been parsed yet.
+func NewCron(tmpFile fileWriterSyncer, executable string, configFile *string, currentCronCmd commandOutputter, saveCronCmd commandRunner) Cron {
     return Cron{
         tmpFile: tmpFile,
+        executable: executable,
+        configFile: configFile,
         saveCronCmd: saveCronCmd,
         currentCronCmd: currentCronCmd,
     }
 }
 
-func (c Cron) addCron(task string) error {
+// add puts the sync in the jrnlSync block of the crontab, replacing whatever was
+// in the block before so running setup again doesn't add a second sync. The
+// task runs the sync with the config file setup saved the profile to, by its
+// full path since cron's PATH is unlikely to have jrnlSync on it.
+func (c Cron) add(schedule Schedule) error {
+    args := append([]string{c.executable, "-config", *c.configFile}, schedule.syncArgs()...)
+    for i, arg := range args {
+        args[i] = cronQuote(arg)
+    }
+    task := fmt.Sprintf("%s %s > ~/.jrnlSyncLogs.txt 2>&1\n", schedule, strings.Join(args, " "))
     output, err := c.currentCronCmd.Output()
     if err != nil {
         return fmt.Errorf("%w: %s", ErrFailedToGetCurrentCron, err)
     }
-    buf := bytes.NewBuffer(output)
-    _, err = buf.Write([]byte(task))
+    crontab, err := replaceManagedBlock(output, fmt.Sprintf("%s\n%s%s\n", cronBlockBegin, task, cronBlockEnd))
     if err != nil {
-        return fmt.Errorf("%w: %s", ErrFailedToWriteNewCronTaskToBuffer, err)
+        return err
     }
+    return c.saveCrontab(crontab)
+}
 
-    _, err = c.tmpFile.Write(buf.Bytes())
+// cronQuote single quotes an argument for the shell cron runs the task with, a
+// % has to be escaped as cron would otherwise turn it into a newline
+func cronQuote(arg string) string {
+    if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!%") {
+        return arg
+    }
+    quoted := "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+    return strings.ReplaceAll(quoted, "%", `\%`)
+}
+
+// remove takes the jrnlSync block out of the crontab, reporting whether there
+// was anything to take out.
+func (c Cron) remove() (bool, error) {
+    output, err := c.currentCronCmd.Output()
+    if noCrontab(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("%w: %s", ErrFailedToGetCurrentCron, err)
+    }
+    crontab, err := replaceManagedBlock(output, "")
+    if err != nil {
+        return false, err
+    }
+    if bytes.Equal(crontab, output) {
+        return false, nil
+    }
+    return true, c.saveCrontab(crontab)
+}
+
+// noCrontab is crontab -l failing because cron isn't installed or there's no
+// crontab for the user yet, neither of which can have a jrnlSync task in it.
+func noCrontab(err error) bool {
+    var exitErr *exec.ExitError
+    if errors.As(err, &exitErr) {
+        return bytes.Contains(exitErr.Stderr, []byte("no crontab for"))
+    }
+    return errors.Is(err, exec.ErrNotFound)
+}
+
+func (c Cron) saveCrontab(crontab []byte) error {
+    _, err := c.tmpFile.Write(crontab)
     if err != nil {
         return fmt.Errorf("%w: %s", ErrFailedToWriteNewCronFile, err)
     }
@@ -74,3 +143,40 @@ func (c Cron) addCron(task string) error {
     return nil
 }
 
+
+// replaceManagedBlock swaps the jrnlSync block in crontab for block, or adds it
+// to the end when there isn't one, leaving every other line as it was.
+func replaceManagedBlock(crontab []byte, block string) ([]byte, error) {
+    lines := bytes.SplitAfter(crontab, []byte("\n"))
+    out := bytes.NewBuffer(make([]byte, 0, len(crontab)+len(block)))
+    written := false
+    inBlock := false
+    for _, line := range lines {
+        trimmed := string(bytes.TrimSpace(line))
+        switch {
+        case inBlock:
+            if trimmed == cronBlockEnd {
+                inBlock = false
+            }
+        case trimmed == cronBlockBegin:
+            inBlock = true
+            if !written {
+                out.WriteString(block)
+                written = true
+            }
+        case legacyCronLineRegex.MatchString(trimmed):
+        default:
+            out.Write(line)
+        }
+    }
+    if inBlock {
+        return nil, ErrUnterminatedCronBlock
+    }
+    if !written && block != "" {
+        if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+            out.WriteString("\n")
+        }
+        out.WriteString(block)
+    }
+    return out.Bytes(), nil
+}
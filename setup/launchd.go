@@ -0,0 +1,220 @@
+package setup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const launchdLabel = "com.github.jm96441n.jrnlSync"
+
+var ErrFailedToWritePlist = errors.New("failed to write the launchd plist")
+var ErrFailedToRunLaunchctl = errors.New("failed to run launchctl")
+
+type Launchd struct {
+    dir string
+    executable string
+    configFile *string
+    logFile string
+    env map[string]string
+    launchctl func(args ...string) error
+}
+
+type calendarInterval struct {
+    key string
+    values []int
+}
+
+func NewLaunchd(dir, executable string, configFile *string, logFile string, env map[string]string, launchctl func(args ...string) error) Launchd {
+    return Launchd{
+        dir: dir,
+        executable: executable,
+        configFile: configFile,
+        logFile: logFile,
+        env: env,
+        launchctl: launchctl,
+    }
+}
+
+func DefaultLaunchAgentsDir() string {
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return filepath.Join("Library", "LaunchAgents")
+    }
+    return filepath.Join(home, "Library", "LaunchAgents")
+}
+
+func DefaultLaunchdLogFile() string {
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return filepath.Join("Library", "Logs", "jrnlSync.log")
+    }
+    return filepath.Join(home, "Library", "Logs", "jrnlSync.log")
+}
+
+// LaunchdEnvironment is what the sync needs from the shell setup was run in,
+// launchd jobs get a bare PATH without homebrew so jrnl wouldn't be found.
+func LaunchdEnvironment() map[string]string {
+    env := make(map[string]string)
+    for _, name := range []string{"PATH", "XDG_CONFIG_HOME"} {
+        if value := os.Getenv(name); value != "" {
+            env[name] = value
+        }
+    }
+    return env
+}
+
+func Launchctl(args ...string) error {
+    output, err := exec.Command("launchctl", args...).CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+    }
+    return nil
+}
+
+func (l Launchd) plistPath() string {
+    return filepath.Join(l.dir, launchdLabel+".plist")
+}
+
+func (l Launchd) add(schedule Schedule) error {
+    err := os.MkdirAll(l.dir, 0755)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToWritePlist, err)
+    }
+    err = os.WriteFile(l.plistPath(), l.plist(schedule), 0644)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToWritePlist, err)
+    }
+
+    // unloading fails when the job isn't loaded yet, which is fine
+    l.launchctl("unload", l.plistPath())
+    err = l.launchctl("load", "-w", l.plistPath())
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToRunLaunchctl, err)
+    }
+    return nil
+}
+
+func (l Launchd) remove() (bool, error) {
+    if _, err := os.Stat(l.plistPath()); os.IsNotExist(err) {
+        return false, nil
+    }
+    err := l.launchctl("unload", "-w", l.plistPath())
+    if err != nil {
+        return false, fmt.Errorf("%w: %s", ErrFailedToRunLaunchctl, err)
+    }
+    err = os.Remove(l.plistPath())
+    if err != nil {
+        return false, fmt.Errorf("%w: %s", ErrFailedToRemoveFile, err)
+    }
+    return true, nil
+}
+
+func (l Launchd) plist(schedule Schedule) []byte {
+    buf := bytes.NewBuffer([]byte{})
+    buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+    writePlistString(buf, "\t", "Label", launchdLabel)
+    buf.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+    for _, arg := range append([]string{l.executable, "-config", *l.configFile}, schedule.syncArgs()...) {
+        fmt.Fprintf(buf, "\t\t<string>%s</string>\n", xmlEscape(arg))
+    }
+    buf.WriteString("\t</array>\n")
+
+    if len(l.env) > 0 {
+        names := make([]string, 0, len(l.env))
+        for name := range l.env {
+            names = append(names, name)
+        }
+        sort.Strings(names)
+        buf.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+        for _, name := range names {
+            writePlistString(buf, "\t\t", name, l.env[name])
+        }
+        buf.WriteString("\t</dict>\n")
+    }
+
+    buf.WriteString("\t<key>StartCalendarInterval</key>\n\t<array>\n")
+    for _, interval := range schedule.calendarIntervals() {
+        buf.WriteString("\t\t<dict>\n")
+        for _, field := range interval {
+            fmt.Fprintf(buf, "\t\t\t<key>%s</key>\n\t\t\t<integer>%d</integer>\n", field.key, field.values[0])
+        }
+        buf.WriteString("\t\t</dict>\n")
+    }
+    buf.WriteString("\t</array>\n")
+
+    writePlistString(buf, "\t", "StandardOutPath", l.logFile)
+    writePlistString(buf, "\t", "StandardErrorPath", l.logFile)
+    buf.WriteString("</dict>\n</plist>\n")
+    return buf.Bytes()
+}
+
+func writePlistString(buf *bytes.Buffer, indent, key, value string) {
+    fmt.Fprintf(buf, "%s<key>%s</key>\n%s<string>%s</string>\n", indent, xmlEscape(key), indent, xmlEscape(value))
+}
+
+func xmlEscape(text string) string {
+    buf := bytes.NewBuffer([]byte{})
+    xml.EscapeText(buf, []byte(text))
+    return buf.String()
+}
+
+// calendarIntervals turns the schedule into launchd StartCalendarInterval
+// dicts, one for every combination of the restricted fields since launchd has
+// no lists or ranges. Left out fields match anything. Like cron, when both the
+// day of month and day of week are restricted either one runs.
+func (s Schedule) calendarIntervals() [][]calendarInterval {
+    minute := calendarInterval{key: "Minute", values: restrictedValues(s.minutes[:], 0)}
+    hour := calendarInterval{key: "Hour", values: restrictedValues(s.hours[:], 0)}
+    day := calendarInterval{key: "Day", values: restrictedValues(s.days[1:], 1)}
+    weekday := calendarInterval{key: "Weekday", values: restrictedValues(s.weekdays[:], 0)}
+    month := calendarInterval{key: "Month", values: restrictedValues(s.months[1:], 1)}
+
+    if !s.anyDay && !s.anyWeekday && len(day.values) > 0 && len(weekday.values) > 0 {
+        intervals := combineIntervals([]calendarInterval{minute, hour, day, month})
+        return append(intervals, combineIntervals([]calendarInterval{minute, hour, weekday, month})...)
+    }
+    return combineIntervals([]calendarInterval{minute, hour, day, weekday, month})
+}
+
+// restrictedValues is nil when every value is set so the field is left out
+func restrictedValues(values []bool, offset int) []int {
+    set := make([]int, 0, len(values))
+    for i, ok := range values {
+        if ok {
+            set = append(set, i+offset)
+        }
+    }
+    if len(set) == len(values) {
+        return nil
+    }
+    return set
+}
+
+func combineIntervals(fields []calendarInterval) [][]calendarInterval {
+    combinations := [][]calendarInterval{{}}
+    for _, field := range fields {
+        if len(field.values) == 0 {
+            continue
+        }
+        next := make([][]calendarInterval, 0, len(combinations)*len(field.values))
+        for _, combination := range combinations {
+            for _, value := range field.values {
+                extended := append(append([]calendarInterval{}, combination...), calendarInterval{key: field.key, values: []int{value}})
+                next = append(next, extended)
+            }
+        }
+        combinations = next
+    }
+    return combinations
+}
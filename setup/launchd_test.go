@@ -0,0 +1,155 @@
+package setup_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/setup"
+)
+
+func TestSetupWritesALaunchdPlistAndLoadsIt(t *testing.T) {
+    dir := filepath.Join(t.TempDir(), "Library", "LaunchAgents")
+    configFile := "/Users/me/Library/Application Support/jrnlSync/config.toml"
+    launchctl := &mockCtl{}
+    env := map[string]string{"PATH": "/opt/homebrew/bin:/usr/bin:/bin", "XDG_CONFIG_HOME": "/Users/me/.config"}
+    launchd := setup.NewLaunchd(dir, "/opt/homebrew/bin/jrnlSync", &configFile, "/Users/me/Library/Logs/jrnlSync.log", env, launchctl.run)
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(map[string]setup.Scheduler{setup.SchedulerLaunchd: launchd}, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Scheduler = setup.SchedulerLaunchd
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    plistPath := filepath.Join(dir, "com.github.jm96441n.jrnlSync.plist")
+    contents, err := os.ReadFile(plistPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.github.jm96441n.jrnlSync</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/opt/homebrew/bin/jrnlSync</string>
+		<string>-config</string>
+		<string>/Users/me/Library/Application Support/jrnlSync/config.toml</string>
+		<string>notion</string>
+		<string>-days</string>
+		<string>2</string>
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>/opt/homebrew/bin:/usr/bin:/bin</string>
+		<key>XDG_CONFIG_HOME</key>
+		<string>/Users/me/.config</string>
+	</dict>
+	<key>StartCalendarInterval</key>
+	<array>
+		<dict>
+			<key>Minute</key>
+			<integer>1</integer>
+			<key>Hour</key>
+			<integer>0</integer>
+		</dict>
+	</array>
+	<key>StandardOutPath</key>
+	<string>/Users/me/Library/Logs/jrnlSync.log</string>
+	<key>StandardErrorPath</key>
+	<string>/Users/me/Library/Logs/jrnlSync.log</string>
+</dict>
+</plist>
+`
+    if string(contents) != expected {
+        t.Errorf("Expected the plist to be %q, got %q", expected, string(contents))
+    }
+    expectedCalls := [][]string{{"unload", plistPath}, {"load", "-w", plistPath}}
+    if !reflect.DeepEqual(expectedCalls, launchctl.calls) {
+        t.Errorf("Expected launchctl to be run with %v, got %v", expectedCalls, launchctl.calls)
+    }
+}
+
+func TestLaunchdCalendarIntervalsMatchTheSchedule(t *testing.T) {
+    testCases := []struct{
+        schedule string
+        expected []string
+    }{
+        {schedule: "hourly", expected: []string{"Minute=0"}},
+        {schedule: "weekly", expected: []string{"Minute=1 Hour=0 Weekday=0"}},
+        {schedule: "30 6 * * 1-3", expected: []string{"Minute=30 Hour=6 Weekday=1", "Minute=30 Hour=6 Weekday=2", "Minute=30 Hour=6 Weekday=3"}},
+        {schedule: "0 0,12 1 jan *", expected: []string{"Minute=0 Hour=0 Day=1 Month=1", "Minute=0 Hour=12 Day=1 Month=1"}},
+        // cron runs on the 1st or any sunday, launchd needs a dict for each
+        {schedule: "0 0 1 * sun", expected: []string{"Minute=0 Hour=0 Day=1", "Minute=0 Hour=0 Weekday=0"}},
+    }
+
+    intervalsRegex := regexp.MustCompile(`(?s)<key>StartCalendarInterval</key>\s*<array>(.*?)</array>`)
+    intervalRegex := regexp.MustCompile(`(?s)<dict>(.*?)</dict>`)
+    fieldRegex := regexp.MustCompile(`<key>(\w+)</key>\s*<integer>(\d+)</integer>`)
+    configFile := "config.toml"
+    for _, testCase := range testCases {
+        dir := t.TempDir()
+        launchd := setup.NewLaunchd(dir, "jrnlSync", &configFile, "jrnlSync.log", nil, (&mockCtl{}).run)
+        reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+        c := setup.NewConfig(map[string]setup.Scheduler{setup.SchedulerLaunchd: launchd}, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+        c.Scheduler = setup.SchedulerLaunchd
+        c.Schedule = testCase.schedule
+        err := c.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        contents, err := os.ReadFile(filepath.Join(dir, "com.github.jm96441n.jrnlSync.plist"))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        intervals := make([]string, 0)
+        for _, match := range intervalRegex.FindAllSubmatch(intervalsRegex.FindSubmatch(contents)[1], -1) {
+            interval := ""
+            for _, field := range fieldRegex.FindAllSubmatch(match[1], -1) {
+                if interval != "" {
+                    interval += " "
+                }
+                interval += string(field[1]) + "=" + string(field[2])
+            }
+            intervals = append(intervals, interval)
+        }
+        if !reflect.DeepEqual(testCase.expected, intervals) {
+            t.Errorf("Expected the intervals for %q to be %q, got %q", testCase.schedule, testCase.expected, intervals)
+        }
+    }
+}
+
+func TestUnscheduleUnloadsAndRemovesThePlist(t *testing.T) {
+    dir := t.TempDir()
+    plistPath := filepath.Join(dir, "com.github.jm96441n.jrnlSync.plist")
+    err := os.WriteFile(plistPath, []byte{}, 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    configFile := "config.toml"
+    launchctl := &mockCtl{}
+    launchd := setup.NewLaunchd(dir, "jrnlSync", &configFile, "jrnlSync.log", nil, launchctl.run)
+    c := setup.NewUnscheduleConfig(map[string]setup.Scheduler{setup.SchedulerLaunchd: launchd}, nil, &mockStringReader{}, bytes.NewBuffer([]byte{}))
+    c.Scheduler = setup.SchedulerLaunchd
+    c.Yes = true
+    err = c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := os.Stat(plistPath); !os.IsNotExist(err) {
+        t.Errorf("Expected the plist to be removed")
+    }
+    expectedCalls := [][]string{{"unload", "-w", plistPath}}
+    if !reflect.DeepEqual(expectedCalls, launchctl.calls) {
+        t.Errorf("Expected launchctl to be run with %v, got %v", expectedCalls, launchctl.calls)
+    }
+}
@@ -0,0 +1,223 @@
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultSchedule = "nightly"
+
+var schedulePresets = map[string]string{
+    "nightly": "1 0 * * *",
+    "daily": "1 0 * * *",
+    "hourly": "0 * * * *",
+    "weekly": "1 0 * * 0",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+var ErrInvalidSchedule = errors.New("invalid schedule, use nightly, hourly, weekly or a cron expression like '30 6 * * 1-5'")
+
+type Schedule struct {
+    expression string
+    minutes [60]bool
+    hours [24]bool
+    days [32]bool
+    months [13]bool
+    weekdays [7]bool
+    anyDay bool
+    anyWeekday bool
+}
+
+type scheduleField struct {
+    name string
+    min int
+    max int
+    names []string
+}
+
+// ParseSchedule reads a preset or a standard five field cron expression.
+func ParseSchedule(schedule string) (Schedule, error) {
+    expression := strings.Join(strings.Fields(schedule), " ")
+    if preset, ok := schedulePresets[strings.ToLower(expression)]; ok {
+        expression = preset
+    }
+    fields := strings.Fields(expression)
+    if len(fields) != 5 {
+        return Schedule{}, fmt.Errorf("%w: %q needs 5 fields, got %d", ErrInvalidSchedule, schedule, len(fields))
+    }
+
+    s := Schedule{
+        expression: expression,
+        anyDay: strings.HasPrefix(fields[2], "*"),
+        anyWeekday: strings.HasPrefix(fields[4], "*"),
+    }
+    err := parseScheduleField(fields[0], scheduleField{name: "minute", min: 0, max: 59}, s.minutes[:])
+    if err == nil {
+        err = parseScheduleField(fields[1], scheduleField{name: "hour", min: 0, max: 23}, s.hours[:])
+    }
+    if err == nil {
+        err = parseScheduleField(fields[2], scheduleField{name: "day of month", min: 1, max: 31}, s.days[:])
+    }
+    if err == nil {
+        err = parseScheduleField(fields[3], scheduleField{name: "month", min: 1, max: 12, names: monthNames}, s.months[:])
+    }
+    if err == nil {
+        // 7 is sunday as well as 0
+        weekdays := make([]bool, 8)
+        err = parseScheduleField(fields[4], scheduleField{name: "day of week", min: 0, max: 7, names: dayNames}, weekdays)
+        copy(s.weekdays[:], weekdays)
+        s.weekdays[0] = s.weekdays[0] || weekdays[7]
+    }
+    if err != nil {
+        return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidSchedule, err)
+    }
+    if s.Next(time.Now()).IsZero() {
+        return Schedule{}, fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, schedule)
+    }
+    return s, nil
+}
+
+func parseScheduleField(field string, f scheduleField, values []bool) error {
+    for _, item := range strings.Split(field, ",") {
+        rangePart, stepPart := item, ""
+        if i := strings.Index(item, "/"); i >= 0 {
+            rangePart, stepPart = item[:i], item[i+1:]
+        }
+
+        step := 1
+        if stepPart != "" {
+            var err error
+            step, err = strconv.Atoi(stepPart)
+            if err != nil || step < 1 {
+                return fmt.Errorf("bad step %q in the %s field", stepPart, f.name)
+            }
+        }
+
+        start, end := f.min, f.max
+        switch {
+        case rangePart == "*":
+        case strings.Contains(rangePart, "-"):
+            bounds := strings.SplitN(rangePart, "-", 2)
+            var err error
+            start, err = f.value(bounds[0])
+            if err != nil {
+                return err
+            }
+            end, err = f.value(bounds[1])
+            if err != nil {
+                return err
+            }
+            if start > end {
+                return fmt.Errorf("the range %q in the %s field goes backwards", rangePart, f.name)
+            }
+        default:
+            var err error
+            start, err = f.value(rangePart)
+            if err != nil {
+                return err
+            }
+            // "5/15" means every 15 starting at 5
+            if stepPart == "" {
+                end = start
+            }
+        }
+
+        for v := start; v <= end; v += step {
+            values[v] = true
+        }
+    }
+    return nil
+}
+
+func (f scheduleField) value(text string) (int, error) {
+    for i, name := range f.names {
+        if strings.EqualFold(text, name) {
+            return i + f.min, nil
+        }
+    }
+    v, err := strconv.Atoi(text)
+    if err != nil || v < f.min || v > f.max {
+        return 0, fmt.Errorf("%q isn't a valid %s, use %d-%d", text, f.name, f.min, f.max)
+    }
+    return v, nil
+}
+
+func (s Schedule) String() string {
+    return s.expression
+}
+
+// Next returns the first time after t the schedule runs, or the zero time if it
+// doesn't run in the next five years (e.g. "0 0 30 2 *").
+func (s Schedule) Next(t time.Time) time.Time {
+    t = t.Truncate(time.Minute).Add(time.Minute)
+    limit := t.AddDate(5, 0, 0)
+    for t.Before(limit) {
+        switch {
+        case !s.months[t.Month()]:
+            t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+        case !s.matchesDay(t):
+            t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+        case !s.hours[t.Hour()]:
+            t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+        case !s.minutes[t.Minute()]:
+            t = t.Add(time.Minute)
+        default:
+            return t
+        }
+    }
+    return time.Time{}
+}
+
+// like cron, when both the day of month and day of week are restricted a day
+// matching either one runs
+func (s Schedule) matchesDay(t time.Time) bool {
+    day, weekday := s.days[t.Day()], s.weekdays[t.Weekday()]
+    if s.anyDay || s.anyWeekday {
+        return day && weekday
+    }
+    return day || weekday
+}
+
+// CoverDays is how many days up to and including the day of a run it has to sync
+// to take in every date since the run before it, 2 for a nightly sync (entries
+// from the end of yesterday) and 8 for a weekly one.
+func (s Schedule) CoverDays() int {
+    // eight years takes in a leap day and every month starting on every weekday
+    // bar one, plenty to find the longest gap
+    start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+    end := start.AddDate(8, 0, 0)
+    days := 1
+    last := time.Time{}
+    for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+        if !s.months[day.Month()] || !s.matchesDay(day) {
+            continue
+        }
+        if !last.IsZero() && int(day.Sub(last).Hours()/24)+1 > days {
+            days = int(day.Sub(last).Hours()/24) + 1
+        }
+        last = day
+    }
+    return days
+}
+
+// syncArgs are the arguments the scheduled task runs jrnlSync with.
+func (s Schedule) syncArgs() []string {
+    return []string{"notion", "-days", strconv.Itoa(s.CoverDays())}
+}
+
+func (s Schedule) NextRuns(t time.Time, count int) []time.Time {
+    runs := make([]time.Time, 0, count)
+    for len(runs) < count {
+        t = s.Next(t)
+        if t.IsZero() {
+            break
+        }
+        runs = append(runs, t)
+    }
+    return runs
+}
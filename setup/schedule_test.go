@@ -0,0 +1,92 @@
+package setup_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/setup"
+)
+
+func TestNextRuns(t *testing.T) {
+    // a wednesday
+    now := time.Date(2021, time.November, 24, 15, 30, 45, 0, time.UTC)
+    testCases := []struct{
+        schedule string
+        expected []string
+    }{
+        {schedule: "nightly", expected: []string{"2021-11-25 00:01", "2021-11-26 00:01", "2021-11-27 00:01"}},
+        {schedule: "hourly", expected: []string{"2021-11-24 16:00", "2021-11-24 17:00", "2021-11-24 18:00"}},
+        {schedule: "weekly", expected: []string{"2021-11-28 00:01", "2021-12-05 00:01", "2021-12-12 00:01"}},
+        {schedule: "*/20 15 * * *", expected: []string{"2021-11-24 15:40", "2021-11-25 15:00", "2021-11-25 15:20"}},
+        {schedule: "0 9 * * mon-fri", expected: []string{"2021-11-25 09:00", "2021-11-26 09:00", "2021-11-29 09:00"}},
+        {schedule: "0 0 1,15 * *", expected: []string{"2021-12-01 00:00", "2021-12-15 00:00", "2022-01-01 00:00"}},
+        {schedule: "0 0 29 feb *", expected: []string{"2024-02-29 00:00", "2028-02-29 00:00", "2032-02-29 00:00"}},
+        // either the day of month or the day of week, like cron
+        {schedule: "0 0 1 * 7", expected: []string{"2021-11-28 00:00", "2021-12-01 00:00", "2021-12-05 00:00"}},
+    }
+
+    for _, testCase := range testCases {
+        schedule, err := setup.ParseSchedule(testCase.schedule)
+        if err != nil {
+            t.Fatal(err)
+        }
+        runs := schedule.NextRuns(now, 3)
+        if len(runs) != len(testCase.expected) {
+            t.Fatalf("Expected %d runs for %q, got %d", len(testCase.expected), testCase.schedule, len(runs))
+        }
+        for i, run := range runs {
+            if run.Format("2006-01-02 15:04") != testCase.expected[i] {
+                t.Errorf("Expected run %d of %q to be %s, got %s", i, testCase.schedule, testCase.expected[i], run.Format("2006-01-02 15:04"))
+            }
+        }
+    }
+}
+
+func TestCoverDaysTakesInEveryDateSinceTheRunBefore(t *testing.T) {
+    testCases := []struct{
+        schedule string
+        expected int
+    }{
+        {schedule: "nightly", expected: 2},
+        {schedule: "hourly", expected: 2},
+        {schedule: "weekly", expected: 8},
+        {schedule: "30 6 * * mon-fri", expected: 4},
+        {schedule: "0 0 1,15 * *", expected: 18},
+    }
+
+    for _, testCase := range testCases {
+        schedule, err := setup.ParseSchedule(testCase.schedule)
+        if err != nil {
+            t.Fatal(err)
+        }
+        days := schedule.CoverDays()
+        if days != testCase.expected {
+            t.Errorf("Expected %q to cover %d days, got %d", testCase.schedule, testCase.expected, days)
+        }
+
+        // every date from the first run on is synced by a run on or after it
+        runs := schedule.NextRuns(time.Date(2021, time.November, 24, 15, 30, 0, 0, time.UTC), 40)
+        synced := make(map[string]bool)
+        for _, run := range runs {
+            for i := 0; i < days; i++ {
+                synced[run.AddDate(0, 0, -i).Format("2006-01-02")] = true
+            }
+        }
+        for date := runs[0]; date.Before(runs[len(runs)-1]); date = date.AddDate(0, 0, 1) {
+            if !synced[date.Format("2006-01-02")] {
+                t.Errorf("Expected %q to sync %s", testCase.schedule, date.Format("2006-01-02"))
+            }
+        }
+    }
+}
+
+func TestParseScheduleReturnsErrForInvalidSchedules(t *testing.T) {
+    schedules := []string{"", "sometimes", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 30 feb *"}
+    for _, schedule := range schedules {
+        _, err := setup.ParseSchedule(schedule)
+        if !errors.Is(err, setup.ErrInvalidSchedule) {
+            t.Errorf("Expected error for %q to be ErrInvalidSchedule, got %+v", schedule, err)
+        }
+    }
+}
@@ -0,0 +1,47 @@
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+const (
+    SchedulerCron = "cron"
+    SchedulerSystemd = "systemd"
+    SchedulerLaunchd = "launchd"
+)
+
+var ErrUnknownScheduler = errors.New("unknown scheduler, use cron, systemd or launchd")
+
+// Scheduler is something that can run the nightly sync for us, cron, a systemd
+// timer or a launchd agent.
+type Scheduler interface {
+    add(schedule Schedule) error
+    remove() (bool, error)
+}
+
+// DetectScheduler picks launchd on macOS, where cron needs full disk access and
+// often silently does nothing, then cron when it's installed and falls back to
+// systemd timers on machines that only have systemd.
+func DetectScheduler() string {
+    if runtime.GOOS == "darwin" {
+        return SchedulerLaunchd
+    }
+    if _, err := exec.LookPath("crontab"); err == nil {
+        return SchedulerCron
+    }
+    if _, err := exec.LookPath("systemctl"); err == nil {
+        return SchedulerSystemd
+    }
+    return SchedulerCron
+}
+
+func pickScheduler(schedulers map[string]Scheduler, name string) (Scheduler, error) {
+    scheduler, ok := schedulers[name]
+    if !ok {
+        return nil, fmt.Errorf("%w: %q", ErrUnknownScheduler, name)
+    }
+    return scheduler, nil
+}
@@ -8,68 +8,131 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/jm96441n/jrnlSync/profile"
 	"github.com/peterbourgon/ff/v3/ffcli"
 )
 
 type Config struct {
     Notion bool
+    Profile string
+    KeyEnv string
+    KeyCommand string
+    Schedule string
+    Scheduler string
     reader stringReader
-    cron Cron
+    schedulers map[string]Scheduler
+    profiles profileSaver
+    config configSetter
     out io.Writer
+    now func() time.Time
 }
 
 type stringReader interface {
     ReadString(byte) (string, error)
 }
 
+type profileSaver interface {
+    Save(name string, p profile.Profile) error
+}
+
+type configSetter interface {
+    Set(section, key, value string) error
+}
+
 var ErrFailedToReadInput = errors.New("failed to read input from user")
+var ErrInvalidProfileName = errors.New("profile names can only have letters, numbers, '.', '_' and '-'")
+
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
 
-func NewSetupFlagSet(cron Cron) *ffcli.Command {
-    c := NewConfig(cron, bufio.NewReader(os.Stdin), os.Stdout)
+func NewSetupFlagSet(schedulers map[string]Scheduler, profiles profileSaver, config configSetter) *ffcli.Command {
+    c := NewConfig(schedulers, profiles, config, bufio.NewReader(os.Stdin), os.Stdout)
     setupFlagSet := flag.NewFlagSet("jrnlSync setup", flag.ExitOnError)
     setupFlagSet.BoolVar(&c.Notion, "n", false, "setup syncing to notion")
+    setupFlagSet.StringVar(&c.Profile, "profile", profile.DefaultName, "The name of the profile to save your notion settings to")
+    setupFlagSet.StringVar(&c.KeyEnv, "key-env", "", "Read the notion key from this environment variable when syncing instead of saving it")
+    setupFlagSet.StringVar(&c.KeyCommand, "key-command", "", "Run this command to get the notion key when syncing instead of saving it, e.g. 'pass show notion'")
+    setupFlagSet.StringVar(&c.Schedule, "schedule", DefaultSchedule, "When to sync, nightly, hourly, weekly or a cron expression like '30 6 * * 1-5'")
+    setupFlagSet.StringVar(&c.Scheduler, "scheduler", DetectScheduler(), "What runs the sync, cron, systemd or launchd")
 
     return &ffcli.Command{
         Name:       "setup",
         ShortUsage: "jrnlSync setup [flags]",
-        ShortHelp:  "Sets up the daily cron job to sync your notes to notion",
+        ShortHelp:  "Schedules syncing your notes to notion",
         FlagSet:    setupFlagSet,
         Exec:       c.Exec,
     }
 }
 
-func NewConfig(cron Cron, reader stringReader, out io.Writer) Config {
-    return  Config{
+func NewConfig(schedulers map[string]Scheduler, profiles profileSaver, config configSetter, reader stringReader, out io.Writer) *Config {
+    return  &Config{
+        Profile: profile.DefaultName,
+        Schedule: DefaultSchedule,
+        Scheduler: SchedulerCron,
         reader: reader,
-        cron: cron,
+        schedulers: schedulers,
+        profiles: profiles,
+        config: config,
         out: out,
+        now: time.Now,
     }
 }
 
-func (c Config) Exec(_ context.Context, _ []string) error {
+func (c *Config) Exec(_ context.Context, _ []string) error {
+    if !profileNameRegex.MatchString(c.Profile) {
+        return fmt.Errorf("%w: %q", ErrInvalidProfileName, c.Profile)
+    }
+    schedule, err := ParseSchedule(c.Schedule)
+    if err != nil {
+        return err
+    }
+    scheduler, err := pickScheduler(c.schedulers, c.Scheduler)
+    if err != nil {
+        return err
+    }
     fmt.Fprint(c.out, "Please enter the DB id that the notes will be synced to: ")
     dbid, err := c.reader.ReadString('\n')
     if err != nil {
         return fmt.Errorf("%w: %s", ErrFailedToReadInput, err)
     }
     dbid = strings.ReplaceAll(dbid, "\n", "")
-    fmt.Printf("Please enter your notion integration key: ")
-    notionKey, err := c.reader.ReadString('\n')
+
+    p := profile.Profile{NotionDBID: dbid, NotionKeyEnv: c.KeyEnv, NotionKeyCommand: c.KeyCommand}
+    if c.KeyEnv == "" && c.KeyCommand == "" {
+        fmt.Fprint(c.out, "Please enter your notion integration key: ")
+        notionKey, err := c.reader.ReadString('\n')
+        if err != nil {
+            return fmt.Errorf("%w: %s", ErrFailedToReadInput, err)
+        }
+        p.NotionKey = strings.ReplaceAll(notionKey, "\n", "")
+    }
+
+    err = c.profiles.Save(c.Profile, p)
     if err != nil {
-        return fmt.Errorf("%w: %s", ErrFailedToReadInput, err)
+        return err
     }
-    notionKey = strings.ReplaceAll(notionKey, "\n", "")
+    fmt.Fprintf(c.out, "Saved your notion settings to the %q profile\n", c.Profile)
 
-    fmt.Fprint(c.out, "Scheduling cron task to run every night to sync\n")
+    err = c.config.Set("notion", "profile", c.Profile)
+    if err != nil {
+        return err
+    }
+    fmt.Fprint(c.out, "Saved the profile to use for syncing to your config file\n")
 
-    err = c.cron.addCron(fmt.Sprintf("1 12 * * * jrnlSync notion -d %s -k %s > ~/.jrnlSyncLogs.txt 2>&1\n", dbid, notionKey))
+    fmt.Fprintf(c.out, "Scheduling the sync with %s at %q\n", c.Scheduler, schedule)
+
+    err = scheduler.add(schedule)
     if err != nil {
         return err
     }
 
-    fmt.Fprint(c.out, "Scheduled to run every day right after midinight!\n")
+    fmt.Fprint(c.out, "Scheduled! The next syncs will run at:\n")
+    for _, run := range schedule.NextRuns(c.now(), 3) {
+        fmt.Fprintf(c.out, "  %s\n", run.Format("Mon Jan 2 2006 15:04"))
+    }
 
     return nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/jm96441n/jrnlSync/profile"
 	"github.com/jm96441n/jrnlSync/setup"
 )
 
@@ -14,21 +15,138 @@ func TestCronIsSetWhenThereAreNoCrons(t *testing.T) {
     f := &mockFile{}
     mCC := mockCurrentCronCmd{}
     mSC := mockSaveCronCmd{}
-    cron := setup.NewCron(f, mCC, mSC)
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mCC, mSC)
     responses := []string{"databaseid", "notionkey"}
     readerResponses := make([]string, len(responses))
     copy(readerResponses, responses)
     reader := &mockStringReader{responsesForReadString: readerResponses}
     writeBuf := bytes.NewBuffer([]byte{})
-    c := setup.NewConfig(cron, reader, writeBuf)
+    profiles := &mockProfiles{}
+    c := setup.NewConfig(cronOnly(cron), profiles, &mockConfigFile{}, reader, writeBuf)
     err := c.Exec(context.Background(), []string{})
     if err != nil {
         t.Error(err)
     }
-    expectedCron := fmt.Sprintf("1 12 * * * jrnlSync notion -d %s -k %s > ~/.jrnlSyncLogs.txt 2>&1\n", responses[0], responses[1])
+    expectedCron := managedBlock("1 0 * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n")
     if expectedCron != f.cronCommand {
         t.Errorf("Expected cron string to be %q, got %q", expectedCron, f.cronCommand)
     }
+    expectedProfile := profile.Profile{NotionDBID: responses[0], NotionKey: responses[1]}
+    if profiles.saved["default"] != expectedProfile {
+        t.Errorf("Expected the profile to be saved as %+v, got %+v", expectedProfile, profiles.saved)
+    }
+}
+
+func TestCronRunsTheSyncWithTheConfigFileSetupWroteTo(t *testing.T) {
+    f := &mockFile{}
+    configFile := "/home/me/My Config/it's jrnlSync.toml"
+    cron := setup.NewCron(f, "/usr/local/bin/jrnlSync", &configFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(cronOnly(cron), &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expectedCron := managedBlock(`1 0 * * * /usr/local/bin/jrnlSync -config '/home/me/My Config/it'\''s jrnlSync.toml' notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1` + "\n")
+    if expectedCron != f.cronCommand {
+        t.Errorf("Expected cron string to be %q, got %q", expectedCron, f.cronCommand)
+    }
+}
+
+func TestProfileIsWrittenToTheConfigFileInsteadOfTheCron(t *testing.T) {
+    f := &mockFile{}
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid"}}
+    profiles := &mockProfiles{}
+    configFile := &mockConfigFile{}
+    c := setup.NewConfig(cronOnly(cron), profiles, configFile, reader, bytes.NewBuffer([]byte{}))
+    c.Profile = "work"
+    c.KeyCommand = "pass show notion"
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expectedCron := managedBlock("1 0 * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n")
+    if expectedCron != f.cronCommand {
+        t.Errorf("Expected cron string to be %q, got %q", expectedCron, f.cronCommand)
+    }
+    expectedProfile := profile.Profile{NotionDBID: "databaseid", NotionKeyCommand: "pass show notion"}
+    if profiles.saved["work"] != expectedProfile {
+        t.Errorf("Expected the profile to be saved as %+v, got %+v", expectedProfile, profiles.saved)
+    }
+    if configFile.values["notion.profile"] != "work" {
+        t.Errorf("Expected the notion profile in the config file to be %q, got %q", "work", configFile.values["notion.profile"])
+    }
+    if reader.readCount != 1 {
+        t.Errorf("Expected not to be asked for the key when a key command is given, got asked %d times", reader.readCount)
+    }
+}
+
+func TestCronUsesTheSchedule(t *testing.T) {
+    testCases := []struct{
+        schedule string
+        expectedCron string
+    }{
+        {schedule: "hourly", expectedCron: "0 * * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n"},
+        {schedule: "weekly", expectedCron: "1 0 * * 0 jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 8 > ~/.jrnlSyncLogs.txt 2>&1\n"},
+        {schedule: "30  6 * * mon-fri", expectedCron: "30 6 * * mon-fri jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 4 > ~/.jrnlSyncLogs.txt 2>&1\n"},
+    }
+
+    for _, testCase := range testCases {
+        f := &mockFile{}
+        cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+        reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+        c := setup.NewConfig(cronOnly(cron), &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+        c.Schedule = testCase.schedule
+        err := c.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if managedBlock(testCase.expectedCron) != f.cronCommand {
+            t.Errorf("Expected cron string to be %q, got %q", testCase.expectedCron, f.cronCommand)
+        }
+    }
+}
+
+func TestSetupReturnsErrorForAnInvalidScheduleBeforeSavingAnything(t *testing.T) {
+    f := &mockFile{}
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    profiles := &mockProfiles{}
+    c := setup.NewConfig(cronOnly(cron), profiles, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Schedule = "61 * * * *"
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrInvalidSchedule) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrInvalidSchedule, err)
+    }
+    if reader.readCount != 0 || profiles.saved != nil || f.cronCommand != "" {
+        t.Errorf("Expected nothing to be asked for or saved with an invalid schedule")
+    }
+}
+
+func TestSetupReturnsErrorWhenTheProfileCantBeSaved(t *testing.T) {
+    f := &mockFile{}
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(cronOnly(cron), &mockProfiles{returnErr: true}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, profile.ErrFailedToSaveProfile) {
+        t.Errorf("Expected error to be %q, got %q", profile.ErrFailedToSaveProfile, err)
+    }
+    if f.cronCommand != "" {
+        t.Errorf("Expected the cron not to be set, got %q", f.cronCommand)
+    }
+}
+
+func TestSetupReturnsErrorForAnInvalidProfileName(t *testing.T) {
+    cron := setup.NewCron(&mockFile{}, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(cronOnly(cron), &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Profile = "work; rm -rf ~"
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrInvalidProfileName) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrInvalidProfileName, err)
+    }
 }
 
 func TestCronIsSetWhenAppendingToExistingCrons(t *testing.T) {
@@ -36,35 +154,95 @@ func TestCronIsSetWhenAppendingToExistingCrons(t *testing.T) {
     existingCron := "* * * * * echo \"hello world\"\n"
     mCC := mockCurrentCronCmd{returnVal: []byte(existingCron)}
     mSC := mockSaveCronCmd{}
-    cron := setup.NewCron(f, mCC, mSC)
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mCC, mSC)
     responses := []string{"databaseid", "notionkey"}
     readerResponses := make([]string, len(responses))
     copy(readerResponses, responses)
     reader := &mockStringReader{responsesForReadString: readerResponses}
     writeBuf := bytes.NewBuffer([]byte{})
-    c := setup.NewConfig(cron, reader, writeBuf)
+    profiles := &mockProfiles{}
+    c := setup.NewConfig(cronOnly(cron), profiles, &mockConfigFile{}, reader, writeBuf)
     err := c.Exec(context.Background(), []string{})
     if err != nil {
         t.Error(err)
     }
-    expectedCron := fmt.Sprintf("%s1 12 * * * jrnlSync notion -d %s -k %s > ~/.jrnlSyncLogs.txt 2>&1\n", existingCron, responses[0], responses[1])
+    expectedCron := existingCron + managedBlock("1 0 * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n")
     if expectedCron != f.cronCommand {
         t.Errorf("Expected cron string to be %q, got %q", expectedCron, f.cronCommand)
     }
 }
 
+func TestSetupReplacesItsOwnCronAndKeepsEveryOtherLine(t *testing.T) {
+    before := "# m h dom mon dow command\n*/5 * * * * backup.sh   --quiet\n"
+    after := "\n0 9 * * 1 echo \"monday\"\n"
+    testCases := []struct{
+        name string
+        existingCron string
+        expectedCron string
+    }{
+        {
+            name: "with a block from an earlier setup",
+            existingCron: before + managedBlock("1 0 * * * jrnlSync notion > ~/.jrnlSyncLogs.txt 2>&1\n") + after,
+            expectedCron: before + managedBlock("0 * * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n") + after,
+        },
+        {
+            name: "with lines from setups before the block",
+            existingCron: before + "1 12 * * * jrnlSync notion -profile default > ~/.jrnlSyncLogs.txt 2>&1\n" + after + "1 12 * * * jrnlSync notion > ~/.jrnlSyncLogs.txt 2>&1\n",
+            expectedCron: before + after + managedBlock("0 * * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n"),
+        },
+        {
+            name: "without a newline at the end",
+            existingCron: "*/5 * * * * backup.sh",
+            expectedCron: "*/5 * * * * backup.sh\n" + managedBlock("0 * * * * jrnlSync -config /home/me/.config/jrnlSync/config.toml notion -days 2 > ~/.jrnlSyncLogs.txt 2>&1\n"),
+        },
+    }
+
+    for _, testCase := range testCases {
+        t.Run(testCase.name, func(t *testing.T) {
+            f := &mockFile{}
+            cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte(testCase.existingCron)}, mockSaveCronCmd{})
+            reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+            c := setup.NewConfig(cronOnly(cron), &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+            c.Schedule = "hourly"
+            err := c.Exec(context.Background(), []string{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if testCase.expectedCron != f.cronCommand {
+                t.Errorf("Expected cron string to be %q, got %q", testCase.expectedCron, f.cronCommand)
+            }
+        })
+    }
+}
+
+func TestSetupReturnsErrorForABlockWithoutAnEnd(t *testing.T) {
+    f := &mockFile{}
+    existingCron := "# BEGIN jrnlSync\n1 0 * * * jrnlSync notion > ~/.jrnlSyncLogs.txt 2>&1\n*/5 * * * * backup.sh\n"
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte(existingCron)}, mockSaveCronCmd{})
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(cronOnly(cron), &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrUnterminatedCronBlock) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrUnterminatedCronBlock, err)
+    }
+    if f.cronCommand != "" {
+        t.Errorf("Expected the crontab not to be changed, got %q", f.cronCommand)
+    }
+}
+
 func TestSetupReturnsFailedToReadInputErrorIfInputFails(t *testing.T) {
     f := &mockFile{}
     mCC := mockCurrentCronCmd{returnVal: []byte{}}
     mSC := mockSaveCronCmd{}
-    cron := setup.NewCron(f, mCC, mSC)
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mCC, mSC)
     responses := []string{"databaseid", "notionkey"}
     readerResponses := make([]string, len(responses))
     copy(readerResponses, responses)
     writeBuf := bytes.NewBuffer([]byte{})
+    profiles := &mockProfiles{}
     for i := 1; i <= 2; i++ {
         reader := &mockStringReader{responsesForReadString: readerResponses, errOnRead: true, countToErrOn: i}
-        c := setup.NewConfig(cron, reader, writeBuf)
+        c := setup.NewConfig(cronOnly(cron), profiles, &mockConfigFile{}, reader, writeBuf)
         err := c.Exec(context.Background(), []string{})
         if !errors.Is(err, setup.ErrFailedToReadInput) {
             t.Errorf("Expected to get ErrFailedToReadInput as the error for the %d call to read input", i)
@@ -119,13 +297,14 @@ func TestSetupReturnsErrorWhenSettingCronFails(t *testing.T) {
 
     responses := []string{"databaseid", "notionkey"}
     writeBuf := bytes.NewBuffer([]byte{})
+    profiles := &mockProfiles{}
     for _, testCase := range testCases {
         readerResponses := make([]string, len(responses))
         copy(readerResponses, responses)
         reader := &mockStringReader{responsesForReadString: readerResponses}
 
-        cron := setup.NewCron(testCase.f, testCase.mCC, testCase.mSC)
-        c := setup.NewConfig(cron, reader, writeBuf)
+        cron := setup.NewCron(testCase.f, "jrnlSync", &cronConfigFile, testCase.mCC, testCase.mSC)
+        c := setup.NewConfig(cronOnly(cron), profiles, &mockConfigFile{}, reader, writeBuf)
         err := c.Exec(context.Background(), []string{})
         if !errors.Is(err, testCase.expectedError) {
             t.Errorf("Expected error to be %q, got %q", testCase.expectedError, err)
@@ -134,6 +313,16 @@ func TestSetupReturnsErrorWhenSettingCronFails(t *testing.T) {
 
 }
 
+var cronConfigFile = "/home/me/.config/jrnlSync/config.toml"
+
+func cronOnly(cron setup.Cron) map[string]setup.Scheduler {
+    return map[string]setup.Scheduler{setup.SchedulerCron: cron}
+}
+
+func managedBlock(task string) string {
+    return "# BEGIN jrnlSync\n" + task + "# END jrnlSync\n"
+}
+
 type mockStringReader struct{
     responsesForReadString []string
     readCount int
@@ -185,10 +374,14 @@ func (m *mockFile) Close() error {
 
 type mockCurrentCronCmd struct {
     returnErr bool
+    err error
     returnVal []byte
 }
 
 func (m mockCurrentCronCmd) Output() ([]byte, error) {
+    if m.err != nil {
+        return nil, m.err
+    }
     if m.returnErr {
         return nil, errors.New("error")
     }
@@ -205,3 +398,31 @@ func (m mockSaveCronCmd) Run() error {
     }
     return nil
 }
+
+type mockProfiles struct {
+    returnErr bool
+    saved map[string]profile.Profile
+}
+
+func (m *mockProfiles) Save(name string, p profile.Profile) error {
+    if m.returnErr {
+        return fmt.Errorf("%werror", profile.ErrFailedToSaveProfile)
+    }
+    if m.saved == nil {
+        m.saved = make(map[string]profile.Profile)
+    }
+    m.saved[name] = p
+    return nil
+}
+
+type mockConfigFile struct {
+    values map[string]string
+}
+
+func (m *mockConfigFile) Set(section, key, value string) error {
+    if m.values == nil {
+        m.values = make(map[string]string)
+    }
+    m.values[section+"."+key] = value
+    return nil
+}
@@ -0,0 +1,190 @@
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+    systemdServiceName = "jrnlSync.service"
+    systemdTimerName = "jrnlSync.timer"
+)
+
+var ErrFailedToWriteUnitFile = errors.New("failed to write the systemd unit file")
+var ErrFailedToRunSystemctl = errors.New("failed to run systemctl")
+
+type Systemd struct {
+    dir string
+    executable string
+    configFile *string
+    systemctl func(args ...string) error
+}
+
+// NewSystemd takes the config file as a pointer so its path can come from a
+// flag that hasn't been parsed yet.
+func NewSystemd(dir, executable string, configFile *string, systemctl func(args ...string) error) Systemd {
+    return Systemd{
+        dir: dir,
+        executable: executable,
+        configFile: configFile,
+        systemctl: systemctl,
+    }
+}
+
+func DefaultSystemdDir() string {
+    configHome := os.Getenv("XDG_CONFIG_HOME")
+    if configHome == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return filepath.Join(".config", "systemd", "user")
+        }
+        configHome = filepath.Join(home, ".config")
+    }
+    return filepath.Join(configHome, "systemd", "user")
+}
+
+// Systemctl runs systemctl against the user's own systemd instance.
+func Systemctl(args ...string) error {
+    output, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+    }
+    return nil
+}
+
+func (s Systemd) add(schedule Schedule) error {
+    err := os.MkdirAll(s.dir, 0755)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToWriteUnitFile, err)
+    }
+    err = os.WriteFile(filepath.Join(s.dir, systemdServiceName), []byte(s.service(schedule)), 0644)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToWriteUnitFile, err)
+    }
+    err = os.WriteFile(filepath.Join(s.dir, systemdTimerName), []byte(s.timer(schedule)), 0644)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToWriteUnitFile, err)
+    }
+
+    err = s.systemctl("daemon-reload")
+    if err == nil {
+        err = s.systemctl("enable", "--now", systemdTimerName)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrFailedToRunSystemctl, err)
+    }
+    return nil
+}
+
+func (s Systemd) remove() (bool, error) {
+    service := filepath.Join(s.dir, systemdServiceName)
+    timer := filepath.Join(s.dir, systemdTimerName)
+    _, serviceErr := os.Stat(service)
+    _, timerErr := os.Stat(timer)
+    if os.IsNotExist(serviceErr) && os.IsNotExist(timerErr) {
+        return false, nil
+    }
+
+    if timerErr == nil {
+        err := s.systemctl("disable", "--now", systemdTimerName)
+        if err != nil {
+            return false, fmt.Errorf("%w: %s", ErrFailedToRunSystemctl, err)
+        }
+    }
+    for _, file := range []string{timer, service} {
+        err := os.Remove(file)
+        if err != nil && !os.IsNotExist(err) {
+            return false, fmt.Errorf("%w: %s", ErrFailedToRemoveFile, err)
+        }
+    }
+    err := s.systemctl("daemon-reload")
+    if err != nil {
+        return false, fmt.Errorf("%w: %s", ErrFailedToRunSystemctl, err)
+    }
+    return true, nil
+}
+
+// service runs the sync with the config file setup saved the profile to, so a
+// non default -config given to setup is used by the timer too
+func (s Systemd) service(schedule Schedule) string {
+    args := append([]string{s.executable, "-config", *s.configFile}, schedule.syncArgs()...)
+    for i, arg := range args {
+        // % starts a systemd specifier
+        arg = strings.ReplaceAll(arg, "%", "%%")
+        if strings.ContainsAny(arg, " \t\"'\\") {
+            arg = fmt.Sprintf("%q", arg)
+        }
+        args[i] = arg
+    }
+    return fmt.Sprintf(`[Unit]
+Description=Sync jrnl entries with jrnlSync
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, strings.Join(args, " "))
+}
+
+// Persistent=true runs a sync missed while the machine was off or asleep as
+// soon as it's back.
+func (s Systemd) timer(schedule Schedule) string {
+    onCalendar := ""
+    for _, calendar := range schedule.onCalendar() {
+        onCalendar += fmt.Sprintf("OnCalendar=%s\n", calendar)
+    }
+    return fmt.Sprintf(`[Unit]
+Description=Run jrnlSync on a schedule
+
+[Timer]
+%sPersistent=true
+
+[Install]
+WantedBy=timers.target
+`, onCalendar)
+}
+
+// onCalendar turns the schedule into systemd calendar events. Systemd needs a
+// day to match both the day of month and day of week, so when cron would run on
+// either we need one event for each.
+func (s Schedule) onCalendar() []string {
+    days := calendarValues(s.days[1:], 1, "%02d")
+    names := make([]string, 0, len(s.weekdays))
+    for i, ok := range s.weekdays {
+        if ok {
+            names = append(names, strings.ToUpper(dayNames[i][:1])+dayNames[i][1:])
+        }
+    }
+    weekdays := strings.Join(names, ",")
+    if len(names) == len(s.weekdays) {
+        weekdays = "*"
+    }
+
+    event := func(weekdays, days string) string {
+        date := fmt.Sprintf("*-%s-%s %s:%s:00", calendarValues(s.months[1:], 1, "%02d"), days, calendarValues(s.hours[:], 0, "%02d"), calendarValues(s.minutes[:], 0, "%02d"))
+        if weekdays == "*" {
+            return date
+        }
+        return weekdays + " " + date
+    }
+    if !s.anyDay && !s.anyWeekday {
+        return []string{event("*", days), event(weekdays, "*")}
+    }
+    return []string{event(weekdays, days)}
+}
+
+func calendarValues(values []bool, offset int, format string) string {
+    set := make([]string, 0, len(values))
+    for i, ok := range values {
+        if ok {
+            set = append(set, fmt.Sprintf(format, i+offset))
+        }
+    }
+    if len(set) == len(values) {
+        return "*"
+    }
+    return strings.Join(set, ",")
+}
@@ -0,0 +1,175 @@
+package setup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/setup"
+)
+
+func TestSetupWritesSystemdUnitsAndEnablesTheTimer(t *testing.T) {
+    dir := filepath.Join(t.TempDir(), "systemd", "user")
+    configFile := "/home/me/My Config/jrnlSync.toml"
+    systemctl := &mockCtl{}
+    schedulers := map[string]setup.Scheduler{setup.SchedulerSystemd: setup.NewSystemd(dir, "/usr/local/bin/jrnlSync", &configFile, systemctl.run)}
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(schedulers, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Scheduler = setup.SchedulerSystemd
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedService := `[Unit]
+Description=Sync jrnl entries with jrnlSync
+
+[Service]
+Type=oneshot
+ExecStart=/usr/local/bin/jrnlSync -config "/home/me/My Config/jrnlSync.toml" notion -days 2
+`
+    expectedTimer := `[Unit]
+Description=Run jrnlSync on a schedule
+
+[Timer]
+OnCalendar=*-*-* 00:01:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`
+    for file, expected := range map[string]string{"jrnlSync.service": expectedService, "jrnlSync.timer": expectedTimer} {
+        contents, err := os.ReadFile(filepath.Join(dir, file))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if string(contents) != expected {
+            t.Errorf("Expected %s to be %q, got %q", file, expected, string(contents))
+        }
+    }
+    expectedCalls := [][]string{{"daemon-reload"}, {"enable", "--now", "jrnlSync.timer"}}
+    if !reflect.DeepEqual(expectedCalls, systemctl.calls) {
+        t.Errorf("Expected systemctl to be run with %v, got %v", expectedCalls, systemctl.calls)
+    }
+}
+
+func TestSystemdTimersMatchTheSchedule(t *testing.T) {
+    testCases := []struct{
+        schedule string
+        expected []string
+    }{
+        {schedule: "hourly", expected: []string{"*-*-* *:00:00"}},
+        {schedule: "weekly", expected: []string{"Sun *-*-* 00:01:00"}},
+        {schedule: "*/15 6-8 * * mon-fri", expected: []string{"Mon,Tue,Wed,Thu,Fri *-*-* 06,07,08:00,15,30,45:00"}},
+        {schedule: "0 0 1,15 jan,jul *", expected: []string{"*-01,07-01,15 00:00:00"}},
+        {schedule: "0 0 */10 * *", expected: []string{"*-*-01,11,21,31 00:00:00"}},
+        // cron runs on the 1st or any sunday, systemd needs both to match
+        {schedule: "0 0 1 * 7", expected: []string{"*-*-01 00:00:00", "Sun *-*-* 00:00:00"}},
+    }
+
+    configFile := "config.toml"
+    for _, testCase := range testCases {
+        dir := t.TempDir()
+        schedulers := map[string]setup.Scheduler{setup.SchedulerSystemd: setup.NewSystemd(dir, "jrnlSync", &configFile, (&mockCtl{}).run)}
+        reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+        c := setup.NewConfig(schedulers, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+        c.Scheduler = setup.SchedulerSystemd
+        c.Schedule = testCase.schedule
+        err := c.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        contents, err := os.ReadFile(filepath.Join(dir, "jrnlSync.timer"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        onCalendar := make([]string, 0)
+        for _, line := range strings.Split(string(contents), "\n") {
+            if strings.HasPrefix(line, "OnCalendar=") {
+                onCalendar = append(onCalendar, strings.TrimPrefix(line, "OnCalendar="))
+            }
+        }
+        if !reflect.DeepEqual(testCase.expected, onCalendar) {
+            t.Errorf("Expected OnCalendar for %q to be %q, got %q", testCase.schedule, testCase.expected, onCalendar)
+        }
+    }
+}
+
+func TestUnscheduleDisablesTheTimerAndRemovesTheUnits(t *testing.T) {
+    dir := t.TempDir()
+    configFile := "config.toml"
+    systemctl := &mockCtl{}
+    schedulers := map[string]setup.Scheduler{setup.SchedulerSystemd: setup.NewSystemd(dir, "jrnlSync", &configFile, systemctl.run)}
+    for _, file := range []string{"jrnlSync.service", "jrnlSync.timer"} {
+        err := os.WriteFile(filepath.Join(dir, file), []byte{}, 0644)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    c := setup.NewUnscheduleConfig(schedulers, nil, &mockStringReader{}, bytes.NewBuffer([]byte{}))
+    c.Scheduler = setup.SchedulerSystemd
+    c.Yes = true
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, file := range []string{"jrnlSync.service", "jrnlSync.timer"} {
+        if _, err := os.Stat(filepath.Join(dir, file)); !os.IsNotExist(err) {
+            t.Errorf("Expected %s to be removed", file)
+        }
+    }
+    expectedCalls := [][]string{{"disable", "--now", "jrnlSync.timer"}, {"daemon-reload"}}
+    if !reflect.DeepEqual(expectedCalls, systemctl.calls) {
+        t.Errorf("Expected systemctl to be run with %v, got %v", expectedCalls, systemctl.calls)
+    }
+
+    systemctl.calls = nil
+    err = c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(systemctl.calls) != 0 {
+        t.Errorf("Expected systemctl not to be run when there's nothing to remove, got %v", systemctl.calls)
+    }
+}
+
+func TestSetupReturnsErrorWhenSystemctlFails(t *testing.T) {
+    configFile := "config.toml"
+    schedulers := map[string]setup.Scheduler{setup.SchedulerSystemd: setup.NewSystemd(t.TempDir(), "jrnlSync", &configFile, (&mockCtl{returnErr: true}).run)}
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(schedulers, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Scheduler = setup.SchedulerSystemd
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrFailedToRunSystemctl) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrFailedToRunSystemctl, err)
+    }
+}
+
+func TestSetupReturnsErrorForAnUnknownScheduler(t *testing.T) {
+    reader := &mockStringReader{responsesForReadString: []string{"databaseid", "notionkey"}}
+    c := setup.NewConfig(map[string]setup.Scheduler{}, &mockProfiles{}, &mockConfigFile{}, reader, bytes.NewBuffer([]byte{}))
+    c.Scheduler = "anacron"
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrUnknownScheduler) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrUnknownScheduler, err)
+    }
+}
+
+type mockCtl struct {
+    returnErr bool
+    calls [][]string
+}
+
+func (m *mockCtl) run(args ...string) error {
+    if m.returnErr {
+        return errors.New("error")
+    }
+    m.calls = append(m.calls, args)
+    return nil
+}
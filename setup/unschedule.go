@@ -0,0 +1,142 @@
+package setup
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type UnscheduleConfig struct {
+    Yes bool
+    Purge bool
+    Scheduler string
+    files []*string
+    reader stringReader
+    schedulers map[string]Scheduler
+    out io.Writer
+}
+
+// NewUnscheduleFlagSet takes the files setup generated as pointers so their
+// paths can come from flags that haven't been parsed yet, they're only
+// removed with -purge.
+func NewUnscheduleFlagSet(schedulers map[string]Scheduler, files []*string) *ffcli.Command {
+    c := NewUnscheduleConfig(schedulers, files, bufio.NewReader(os.Stdin), os.Stdout)
+    unscheduleFlagSet := flag.NewFlagSet("jrnlSync unschedule", flag.ExitOnError)
+    unscheduleFlagSet.BoolVar(&c.Yes, "yes", false, "Don't ask before removing anything")
+    unscheduleFlagSet.BoolVar(&c.Purge, "purge", false, "Also delete the config and profiles files setup wrote")
+    unscheduleFlagSet.StringVar(&c.Scheduler, "scheduler", "", "Only remove the task from cron, systemd or launchd, by default it's removed from all of them")
+
+    return &ffcli.Command{
+        Name:       "unschedule",
+        ShortUsage: "jrnlSync unschedule [flags]",
+        ShortHelp:  "Removes the scheduled sync setup added",
+        FlagSet:    unscheduleFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func NewUnscheduleConfig(schedulers map[string]Scheduler, files []*string, reader stringReader, out io.Writer) *UnscheduleConfig {
+    return &UnscheduleConfig{
+        files: files,
+        reader: reader,
+        schedulers: schedulers,
+        out: out,
+    }
+}
+
+func (c *UnscheduleConfig) Exec(_ context.Context, _ []string) error {
+    names, err := c.schedulerNames()
+    if err != nil {
+        return err
+    }
+    files := make([]string, 0, len(c.files))
+    if c.Purge {
+        for _, file := range c.files {
+            if _, err := os.Stat(*file); err == nil {
+                files = append(files, *file)
+            }
+        }
+    }
+
+    if !c.Yes {
+        fmt.Fprintf(c.out, "This will remove the jrnlSync %s task", joinNames(names, "and"))
+        if len(files) > 0 {
+            fmt.Fprintf(c.out, " and delete %s", strings.Join(files, ", "))
+        }
+        fmt.Fprint(c.out, ". Continue? [y/N]: ")
+        answer, err := c.reader.ReadString('\n')
+        if err != nil {
+            return fmt.Errorf("%w: %s", ErrFailedToReadInput, err)
+        }
+        answer = strings.ToLower(strings.TrimSpace(answer))
+        if answer != "y" && answer != "yes" {
+            fmt.Fprint(c.out, "Nothing was removed\n")
+            return nil
+        }
+    }
+
+    // setup may have used a scheduler other than the one we'd pick now, so every
+    // one of them is checked, and the files a task still needs are kept when
+    // one couldn't be removed
+    removedAny := false
+    var removeErr error
+    for _, name := range names {
+        removed, err := c.schedulers[name].remove()
+        if err != nil {
+            fmt.Fprintf(c.out, "Couldn't remove the jrnlSync %s task: %s\n", name, err)
+            if removeErr == nil {
+                removeErr = err
+            }
+            continue
+        }
+        if removed {
+            removedAny = true
+            fmt.Fprintf(c.out, "Removed the jrnlSync %s task\n", name)
+        }
+    }
+    if removeErr != nil {
+        return removeErr
+    }
+    if !removedAny {
+        fmt.Fprintf(c.out, "There was no jrnlSync %s task to remove\n", joinNames(names, "or"))
+    }
+
+    for _, file := range files {
+        err := os.Remove(file)
+        if err != nil {
+            return fmt.Errorf("%w: %s", ErrFailedToRemoveFile, err)
+        }
+        fmt.Fprintf(c.out, "Deleted %s\n", file)
+    }
+    return nil
+}
+
+func (c *UnscheduleConfig) schedulerNames() ([]string, error) {
+    if c.Scheduler != "" {
+        _, err := pickScheduler(c.schedulers, c.Scheduler)
+        if err != nil {
+            return nil, err
+        }
+        return []string{c.Scheduler}, nil
+    }
+    names := make([]string, 0, len(c.schedulers))
+    for name := range c.schedulers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+func joinNames(names []string, conjunction string) string {
+    if len(names) < 2 {
+        return strings.Join(names, "")
+    }
+    return fmt.Sprintf("%s %s %s", strings.Join(names[:len(names)-1], ", "), conjunction, names[len(names)-1])
+}
@@ -0,0 +1,168 @@
+package setup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/setup"
+)
+
+func TestUnscheduleRemovesTheBlockAndKeepsEveryOtherLine(t *testing.T) {
+    existingCron := "*/5 * * * * backup.sh\n" + managedBlock("1 0 * * * jrnlSync notion > ~/.jrnlSyncLogs.txt 2>&1\n") + "0 9 * * 1 echo \"monday\"\n"
+    f := &mockFile{}
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte(existingCron)}, mockSaveCronCmd{})
+    c := setup.NewUnscheduleConfig(cronOnly(cron), nil, &mockStringReader{}, bytes.NewBuffer([]byte{}))
+    c.Yes = true
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expectedCron := "*/5 * * * * backup.sh\n0 9 * * 1 echo \"monday\"\n"
+    if expectedCron != f.cronCommand {
+        t.Errorf("Expected cron string to be %q, got %q", expectedCron, f.cronCommand)
+    }
+}
+
+func TestUnscheduleAsksBeforeRemovingAnything(t *testing.T) {
+    existingCron := managedBlock("1 0 * * * jrnlSync notion > ~/.jrnlSyncLogs.txt 2>&1\n")
+    testCases := []struct{
+        answer string
+        expectedCron string
+    }{
+        {answer: "y\n", expectedCron: ""},
+        {answer: "Yes\n", expectedCron: ""},
+        {answer: "\n", expectedCron: "unchanged"},
+        {answer: "no\n", expectedCron: "unchanged"},
+    }
+
+    for _, testCase := range testCases {
+        f := &mockFile{cronCommand: "unchanged"}
+        cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte(existingCron)}, mockSaveCronCmd{})
+        reader := &mockStringReader{responsesForReadString: []string{testCase.answer}}
+        c := setup.NewUnscheduleConfig(cronOnly(cron), nil, reader, bytes.NewBuffer([]byte{}))
+        err := c.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if testCase.expectedCron != f.cronCommand {
+            t.Errorf("Expected cron string after answering %q to be %q, got %q", testCase.answer, testCase.expectedCron, f.cronCommand)
+        }
+    }
+}
+
+func TestUnscheduleLeavesTheCrontabAloneWhenThereIsNothingToRemove(t *testing.T) {
+    f := &mockFile{cronCommand: "unchanged"}
+    cron := setup.NewCron(f, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte("*/5 * * * * backup.sh\n")}, mockSaveCronCmd{})
+    out := bytes.NewBuffer([]byte{})
+    c := setup.NewUnscheduleConfig(cronOnly(cron), nil, &mockStringReader{}, out)
+    c.Yes = true
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if f.cronCommand != "unchanged" {
+        t.Errorf("Expected the crontab not to be written, got %q", f.cronCommand)
+    }
+    if out.String() != "There was no jrnlSync cron task to remove\n" {
+        t.Errorf("Expected to be told there was nothing to remove, got %q", out.String())
+    }
+}
+
+func TestUnscheduleOnlyDeletesFilesWithPurge(t *testing.T) {
+    dir := t.TempDir()
+    configFile := filepath.Join(dir, "config.toml")
+    profilesFile := filepath.Join(dir, "profiles.yaml")
+    for _, file := range []string{configFile, profilesFile} {
+        err := os.WriteFile(file, []byte{}, 0600)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    for _, purge := range []bool{false, true} {
+        cron := setup.NewCron(&mockFile{}, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{}, mockSaveCronCmd{})
+        c := setup.NewUnscheduleConfig(cronOnly(cron), []*string{&configFile, &profilesFile}, &mockStringReader{}, bytes.NewBuffer([]byte{}))
+        c.Yes = true
+        c.Purge = purge
+        err := c.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, file := range []string{configFile, profilesFile} {
+            _, err := os.Stat(file)
+            if purge != os.IsNotExist(err) {
+                t.Errorf("Expected %s to be deleted to be %t with purge %t", file, purge, purge)
+            }
+        }
+    }
+}
+
+func TestUnscheduleRemovesTheTaskFromEveryScheduler(t *testing.T) {
+    dir := t.TempDir()
+    configFile := filepath.Join(dir, "config.toml")
+    for _, file := range []string{configFile, filepath.Join(dir, "jrnlSync.service"), filepath.Join(dir, "jrnlSync.timer")} {
+        err := os.WriteFile(file, []byte{}, 0600)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+    systemctl := &mockCtl{}
+    schedulers := map[string]setup.Scheduler{
+        setup.SchedulerCron: setup.NewCron(&mockFile{}, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{err: &exec.Error{Name: "crontab", Err: exec.ErrNotFound}}, mockSaveCronCmd{}),
+        setup.SchedulerSystemd: setup.NewSystemd(dir, "jrnlSync", &configFile, systemctl.run),
+        setup.SchedulerLaunchd: setup.NewLaunchd(dir, "jrnlSync", &configFile, "jrnlSync.log", nil, (&mockCtl{}).run),
+    }
+    out := bytes.NewBuffer([]byte{})
+    c := setup.NewUnscheduleConfig(schedulers, []*string{&configFile}, &mockStringReader{}, out)
+    c.Yes = true
+    c.Purge = true
+    err := c.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(systemctl.calls) == 0 {
+        t.Errorf("Expected the systemd timer to be disabled")
+    }
+    if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+        t.Errorf("Expected the config file to be deleted")
+    }
+    expected := "Removed the jrnlSync systemd task\nDeleted " + configFile + "\n"
+    if out.String() != expected {
+        t.Errorf("Expected the output to be %q, got %q", expected, out.String())
+    }
+}
+
+func TestUnscheduleKeepsTheFilesWhenATaskCouldntBeRemoved(t *testing.T) {
+    dir := t.TempDir()
+    configFile := filepath.Join(dir, "config.toml")
+    for _, file := range []string{configFile, filepath.Join(dir, "jrnlSync.timer")} {
+        err := os.WriteFile(file, []byte{}, 0600)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+    schedulers := map[string]setup.Scheduler{
+        setup.SchedulerCron: setup.NewCron(&mockFile{}, "jrnlSync", &cronConfigFile, mockCurrentCronCmd{returnVal: []byte(managedBlock("1 0 * * * jrnlSync notion\n"))}, mockSaveCronCmd{}),
+        setup.SchedulerSystemd: setup.NewSystemd(dir, "jrnlSync", &configFile, (&mockCtl{returnErr: true}).run),
+    }
+    out := bytes.NewBuffer([]byte{})
+    c := setup.NewUnscheduleConfig(schedulers, []*string{&configFile}, &mockStringReader{}, out)
+    c.Yes = true
+    c.Purge = true
+    err := c.Exec(context.Background(), []string{})
+    if !errors.Is(err, setup.ErrFailedToRunSystemctl) {
+        t.Errorf("Expected error to be %q, got %q", setup.ErrFailedToRunSystemctl, err)
+    }
+    if _, err := os.Stat(configFile); err != nil {
+        t.Errorf("Expected the config file to be kept while the timer is still there")
+    }
+    if !strings.Contains(out.String(), "Removed the jrnlSync cron task\n") {
+        t.Errorf("Expected the cron task to still be removed, got %q", out.String())
+    }
+}
@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const (
+    PrivateActionSkip = "skip"
+    PrivateActionPlaceholder = "placeholder"
+)
+
+type EntryFilter struct {
+    IncludeTags tagList
+    ExcludeTags tagList
+    PrivateTag string
+    PrivateAction string
+    Placeholder string
+}
+
+type tagList []string
+
+var ErrUnknownPrivateAction = errors.New("unknown private action, use skip or placeholder: ")
+
+func (l *tagList) String() string {
+    return strings.Join(*l, ",")
+}
+
+func (l *tagList) Set(tags string) error {
+    for _, tag := range strings.Split(tags, ",") {
+        if tag = strings.TrimSpace(tag); tag != "" {
+            *l = append(*l, tag)
+        }
+    }
+    return nil
+}
+
+func (f *EntryFilter) RegisterFlags(fs *flag.FlagSet) {
+    fs.Var(&f.IncludeTags, "include-tag", "Only send entries with this tag, can be repeated")
+    fs.Var(&f.ExcludeTags, "exclude-tag", "Never send entries with this tag, can be repeated")
+    fs.StringVar(&f.PrivateTag, "private-tag", "", "Entries with this tag are private and are never sent as written")
+    fs.StringVar(&f.PrivateAction, "private-action", PrivateActionSkip, "What to do with private entries, skip or placeholder")
+    fs.StringVar(&f.Placeholder, "private-placeholder", "Private entry", "The text private entries are replaced with")
+}
+
+func (f *EntryFilter) validate() error {
+    switch f.PrivateAction {
+    case "", PrivateActionSkip, PrivateActionPlaceholder:
+        return nil
+    }
+    return fmt.Errorf("%w%q", ErrUnknownPrivateAction, f.PrivateAction)
+}
+
+func (f *EntryFilter) apply(e Entry) (Entry, bool) {
+    if f == nil {
+        return e, true
+    }
+    if len(f.IncludeTags) > 0 && !hasAnyTag(e, f.IncludeTags) {
+        return e, false
+    }
+    if hasAnyTag(e, f.ExcludeTags) {
+        return e, false
+    }
+    if f.PrivateTag == "" || !hasAnyTag(e, []string{f.PrivateTag}) {
+        return e, true
+    }
+    if f.PrivateAction != PrivateActionPlaceholder {
+        return e, false
+    }
+    // nothing that was written is kept, tags included, only when it was
+    return Entry{Title: f.Placeholder, Date: e.Date, Time: e.Time, Tags: []string{}}, true
+}
+
+// isPlaceholder is whether text is what sync wrote for a private entry, which
+// is nothing to pull back into jrnl
+func (f *EntryFilter) isPlaceholder(text string) bool {
+    if f.PrivateAction != PrivateActionPlaceholder || f.Placeholder == "" {
+        return false
+    }
+    e := parseEntryText("", text)
+    return e.Body == f.Placeholder || (e.Title == f.Placeholder && e.Body == "")
+}
+
+// hasAnyTag matches tags case insensitively, with or without their symbol so
+// both -exclude-tag work and -exclude-tag @work match @work.
+func hasAnyTag(e Entry, tags []string) bool {
+    for _, want := range tags {
+        want = strings.ToLower(want)
+        for _, tag := range e.Tags {
+            tag = strings.ToLower(tag)
+            if tag == want || (len(tag) > 1 && tag[1:] == want) {
+                return true
+            }
+        }
+    }
+    return false
+}
@@ -0,0 +1,41 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+type JoplinNote struct {
+    ID string `json:"id,omitempty"`
+    Title string `json:"title,omitempty"`
+    Body string `json:"body"`
+    ParentID string `json:"parent_id,omitempty"`
+}
+
+type joplinNotesPage struct {
+    Items []JoplinNote `json:"items"`
+    HasMore bool `json:"has_more"`
+}
+
+func newJoplinNote(date string, entries []Entry, config *JoplinConfig) JoplinNote {
+    var body strings.Builder
+    for i, e := range entries {
+        if i > 0 {
+            body.WriteString("\n")
+        }
+        heading := strings.TrimSpace(fmt.Sprintf("%s %s", e.Time, e.Title))
+        if e.Starred {
+            heading = fmt.Sprintf("%s ★", heading)
+        }
+        fmt.Fprintf(&body, "## %s\n\n", heading)
+        if e.Body != "" {
+            fmt.Fprintf(&body, "%s\n", strings.TrimRight(e.Body, "\n"))
+        }
+    }
+
+    return JoplinNote{
+        Title: date,
+        Body: body.String(),
+        ParentID: config.NotebookID,
+    }
+}
@@ -0,0 +1,155 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+const joplinDefaultPort = 41184
+
+type JoplinConfig struct {
+    NotebookID string
+    Token string
+    Host string
+    Port int
+    HttpClient httpInteractor
+    Cmd commandOutputter
+    Filter EntryFilter
+    Redact Redactor
+    DateForEntries string
+    Days int
+    Today string
+}
+
+var ErrRequestingJoplin = errors.New("internal error making request to joplin: ")
+var ErrJoplinHTTPStatus = errors.New("request to joplin failed with status code: ")
+var ErrFailedToDecodeJoplinResponse = errors.New("failed to decode joplin response: ")
+
+func NewJoplinSyncFlagSet(httpClient httpInteractor, cmd commandOutputter, dateForEntries string) *ffcli.Command {
+    c := &JoplinConfig{HttpClient: httpClient, Cmd: cmd, DateForEntries: dateForEntries, Today: time.Now().Format("2006-01-02")}
+    syncFlagSet := flag.NewFlagSet("jrnlsync joplin", flag.ExitOnError)
+    syncFlagSet.StringVar(&c.NotebookID, "n", "", "The id of the joplin notebook to put the daily journal note")
+    syncFlagSet.StringVar(&c.Token, "t", "", "Your joplin web clipper authorization token")
+    syncFlagSet.StringVar(&c.Host, "host", "localhost", "The host the joplin data api is listening on")
+    syncFlagSet.IntVar(&c.Port, "p", joplinDefaultPort, "The port the joplin data api is listening on")
+    syncFlagSet.StringVar(&c.DateForEntries, "date", dateForEntries, "Sync the entries from this date (YYYY-MM-DD) instead of yesterday")
+    syncFlagSet.IntVar(&c.Days, "days", 0, "Sync each date with entries in the last N days, up to and including today, instead of one date")
+    c.Filter.RegisterFlags(syncFlagSet)
+    c.Redact.RegisterFlags(syncFlagSet)
+    registerSourceFlags(syncFlagSet, cmd)
+
+    return &ffcli.Command{
+        Name:       "joplin",
+        ShortUsage: "jrnlSync joplin -n [NOTEBOOK_ID] -t [JOPLIN_TOKEN]",
+        ShortHelp:  "Syncs notes from yesterday to a note in your joplin notebook for backup",
+        FlagSet:    syncFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *JoplinConfig) Exec(_ context.Context, _ []string) error {
+    dates, err := datesToSync(c.DateForEntries, c.Today, c.Days)
+    if err != nil {
+        return err
+    }
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, dates[0], dates[len(dates)-1], &c.Filter)
+    if err != nil {
+        return err
+    }
+    err = c.Redact.apply(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+    for _, date := range dates {
+        if c.Days > 0 && len(entriesGroupedByDate[date]) == 0 {
+            continue
+        }
+        err = c.syncNote(newJoplinNote(date, entriesGroupedByDate[date], c))
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (c *JoplinConfig) syncNote(note JoplinNote) error {
+    existing, err := c.findNote(note.Title)
+    if err != nil {
+        return err
+    }
+    if existing == nil {
+        return c.request("POST", "/notes", note, nil)
+    }
+    return c.request("PUT", fmt.Sprintf("/notes/%s", existing.ID), JoplinNote{Body: note.Body}, nil)
+}
+
+func (c *JoplinConfig) findNote(title string) (*JoplinNote, error) {
+    for page := 1; ; page++ {
+        notes := joplinNotesPage{}
+        path := fmt.Sprintf("/folders/%s/notes?fields=id,title&page=%d", url.PathEscape(c.NotebookID), page)
+        err := c.request("GET", path, nil, &notes)
+        if err != nil {
+            return nil, err
+        }
+        for _, n := range notes.Items {
+            if n.Title == title {
+                return &n, nil
+            }
+        }
+        if !notes.HasMore {
+            return nil, nil
+        }
+    }
+}
+
+func (c *JoplinConfig) request(method, path string, body interface{}, out interface{}) error {
+    buf := &bytes.Buffer{}
+    if body != nil {
+        jsonBytes, err := json.Marshal(body)
+        if err != nil {
+            return err
+        }
+        buf = bytes.NewBuffer(jsonBytes)
+    }
+
+    u, err := url.Parse(fmt.Sprintf("http://%s:%d%s", c.Host, c.Port, path))
+    if err != nil {
+        return err
+    }
+    query := u.Query()
+    query.Set("token", c.Token)
+    u.RawQuery = query.Encode()
+
+    req, err := http.NewRequest(method, u.String(), buf)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    res, err := c.HttpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrRequestingJoplin, err)
+    }
+    defer res.Body.Close()
+
+    if res.StatusCode > 299 {
+        return fmt.Errorf("%w%s", ErrJoplinHTTPStatus, res.Status)
+    }
+    if out == nil {
+        return nil
+    }
+    err = json.NewDecoder(res.Body).Decode(out)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToDecodeJoplinResponse, err)
+    }
+    return nil
+}
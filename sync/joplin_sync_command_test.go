@@ -0,0 +1,221 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+func TestJoplinExecCreatesNoteWhenNoneExistsForTheDate(t *testing.T) {
+    joplin := newFakeJoplin(t, "mocktoken")
+    defer joplin.server.Close()
+
+    config := newJoplinConfig(t, joplin, "2021-11-24")
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Error(err)
+    }
+
+    if len(joplin.notes) != 1 {
+        t.Fatalf("expected 1 note to be created, got %d", len(joplin.notes))
+    }
+    note := joplin.notes[0]
+    if note.Title != config.DateForEntries {
+        t.Errorf("expected %s for the note title, got %s", config.DateForEntries, note.Title)
+    }
+    if note.ParentID != config.NotebookID {
+        t.Errorf("expected note to be created in notebook %s, got %s", config.NotebookID, note.ParentID)
+    }
+    for _, body := range []string{"The new one", "next one", "the last one"} {
+        if !strings.Contains(note.Body, body) {
+            t.Errorf("expected note body to contain %q, got %q", body, note.Body)
+        }
+    }
+    if strings.Contains(note.Body, "Too early") || strings.Contains(note.Body, "Too late") {
+        t.Errorf("expected note body to only contain entries from %s, got %q", config.DateForEntries, note.Body)
+    }
+}
+
+func TestJoplinExecUpdatesExistingNoteForTheDate(t *testing.T) {
+    joplin := newFakeJoplin(t, "mocktoken")
+    defer joplin.server.Close()
+    joplin.pageSize = 1
+    joplin.notes = []sync.JoplinNote{
+        {ID: "1", Title: "2021-11-23", Body: "old", ParentID: "mocknotebook"},
+        {ID: "2", Title: "2021-11-24", Body: "stale", ParentID: "mocknotebook"},
+    }
+
+    config := newJoplinConfig(t, joplin, "2021-11-24")
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Error(err)
+    }
+
+    if len(joplin.notes) != 2 {
+        t.Fatalf("expected no new notes to be created, got %d notes", len(joplin.notes))
+    }
+    if joplin.notes[0].Body != "old" {
+        t.Errorf("expected note for another date to be untouched, got %q", joplin.notes[0].Body)
+    }
+    if !strings.Contains(joplin.notes[1].Body, "the last one") {
+        t.Errorf("expected existing note to be updated, got %q", joplin.notes[1].Body)
+    }
+}
+
+func TestJoplinExecWithDaysMakesANoteForEachDateWithEntries(t *testing.T) {
+    joplin := newFakeJoplin(t, "mocktoken")
+    defer joplin.server.Close()
+
+    config := newJoplinConfig(t, joplin, "2021-11-24")
+    config.Days = 3
+    config.Today = "2021-11-26"
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    titles := []string{}
+    for _, note := range joplin.notes {
+        titles = append(titles, note.Title)
+    }
+    if strings.Join(titles, " ") != "2021-11-24 2021-11-25" {
+        t.Errorf("expected notes for 2021-11-24 and 2021-11-25, got %q", titles)
+    }
+}
+
+func TestJoplinExecReturnsErrWhenTheTokenIsRejected(t *testing.T) {
+    joplin := newFakeJoplin(t, "mocktoken")
+    defer joplin.server.Close()
+
+    config := newJoplinConfig(t, joplin, "2021-11-24")
+    config.Token = "wrongtoken"
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrJoplinHTTPStatus) {
+        t.Errorf("Expected error to be of type ErrJoplinHTTPStatus, got %+v", err)
+    }
+}
+
+func TestJoplinExecReturnsErrWhenFailingToSendRequest(t *testing.T) {
+    config := sync.JoplinConfig{
+        NotebookID: "mocknotebook",
+        Token: "mocktoken",
+        Host: "localhost",
+        Port: 41184,
+        HttpClient: &mockHTTPClient{errOnDo: true},
+        Cmd: mockCommand{outputString: `{"entries": []}`},
+        DateForEntries: "2021-11-24",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrRequestingJoplin) {
+        t.Errorf("Expected error to be of type ErrRequestingJoplin, got %+v", err)
+    }
+}
+
+func newJoplinConfig(t *testing.T, joplin *fakeJoplin, date string) sync.JoplinConfig {
+    outputString, err := buildOutputString(
+        map[string]string{"body": "Too early", "date": "2021-11-23"},
+        []map[string]string{
+            {"body": "The new one", "date": "2021-11-24"},
+            {"body": "next one", "date": "2021-11-24"},
+            {"body": "the last one", "date": "2021-11-24"},
+        },
+        map[string]string{"body": "Too late", "date": "2021-11-25"},
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    u, err := url.Parse(joplin.server.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    port, err := strconv.Atoi(u.Port())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return sync.JoplinConfig{
+        NotebookID: "mocknotebook",
+        Token: joplin.token,
+        Host: u.Hostname(),
+        Port: port,
+        HttpClient: joplin.server.Client(),
+        Cmd: mockCommand{outputString: outputString},
+        DateForEntries: date,
+    }
+}
+
+type fakeJoplin struct {
+    t *testing.T
+    server *httptest.Server
+    token string
+    notes []sync.JoplinNote
+    pageSize int
+}
+
+func newFakeJoplin(t *testing.T, token string) *fakeJoplin {
+    f := &fakeJoplin{t: t, token: token, pageSize: 10}
+    f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+    return f
+}
+
+func (f *fakeJoplin) handle(w http.ResponseWriter, r *http.Request) {
+    if r.URL.Query().Get("token") != f.token {
+        w.WriteHeader(http.StatusForbidden)
+        return
+    }
+    switch {
+    case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/folders/"):
+        folderID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/folders/"), "/notes")
+        page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+        inFolder := []sync.JoplinNote{}
+        for _, n := range f.notes {
+            if n.ParentID == folderID {
+                inFolder = append(inFolder, sync.JoplinNote{ID: n.ID, Title: n.Title})
+            }
+        }
+        start := (page - 1) * f.pageSize
+        end := start + f.pageSize
+        if end > len(inFolder) {
+            end = len(inFolder)
+        }
+        if start > end {
+            start = end
+        }
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "items": inFolder[start:end],
+            "has_more": end < len(inFolder),
+        })
+    case r.Method == "POST" && r.URL.Path == "/notes":
+        note := sync.JoplinNote{}
+        if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+            f.t.Error(err)
+        }
+        note.ID = fmt.Sprintf("%d", len(f.notes)+1)
+        f.notes = append(f.notes, note)
+        json.NewEncoder(w).Encode(note)
+    case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/notes/"):
+        id := strings.TrimPrefix(r.URL.Path, "/notes/")
+        update := sync.JoplinNote{}
+        if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+            f.t.Error(err)
+        }
+        for i := range f.notes {
+            if f.notes[i].ID == id {
+                f.notes[i].Body = update.Body
+                json.NewEncoder(w).Encode(f.notes[i])
+                return
+            }
+        }
+        w.WriteHeader(http.StatusNotFound)
+    default:
+        w.WriteHeader(http.StatusNotFound)
+    }
+}
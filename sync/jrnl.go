@@ -0,0 +1,229 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandOutputter gives jrnl's json export, decoded from StdoutPipe as it's
+// written, with Wait reporting whether jrnl managed to write all of it.
+type commandOutputter interface {
+    StdoutPipe() (io.ReadCloser, error)
+    Start() error
+    Wait() error
+}
+
+type flagRegisterer interface {
+    RegisterFlags(*flag.FlagSet)
+}
+
+type dateRanger interface {
+    SetDateRange(from, to string)
+}
+
+type JrnlBody struct {
+    Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+    Title string `json:"title"`
+    Body string `json:"body"`
+    Date string `json:"date"`
+    Time string `json:"time"`
+    Tags []string `json:"tags"`
+    Starred bool `json:"starred"`
+}
+
+var ErrJrnlCommandFailed = errors.New("the command to get output from jrnl failed with: ")
+var ErrFailedToUnmarshalJrnlOutput = errors.New("failed to unmarshal jrnl output: ")
+var ErrJrnlImportFailed = errors.New("importing entries into jrnl failed with: ")
+var ErrInvalidDaysToSync = errors.New("invalid days to sync, use 1 or more: ")
+
+type jrnlImporter interface {
+    Import(args []string, r io.Reader) error
+}
+
+type importArger interface {
+    ImportArgs() []string
+}
+
+// CommandImporter runs the jrnl command Name with the arguments for importing
+// into the journal being read, which aren't known until the flags are parsed.
+type CommandImporter struct {
+    Name string
+}
+
+func (i CommandImporter) Import(args []string, r io.Reader) error {
+    cmd := exec.Command(i.Name, args...)
+    cmd.Stdin = r
+    output, err := cmd.CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("%w%s: %s", ErrJrnlImportFailed, err, strings.TrimSpace(string(output)))
+    }
+    return nil
+}
+
+func registerSourceFlags(fs *flag.FlagSet, cmd commandOutputter) {
+    if source, ok := cmd.(flagRegisterer); ok {
+        source.RegisterFlags(fs)
+    }
+}
+
+// datesToSync is the one date given, or with days the dates in the last that many
+// days up to and including today so a scheduled sync can cover every date since
+// the one before it.
+func datesToSync(date, today string, days int) ([]string, error) {
+    if days == 0 {
+        return []string{date}, nil
+    }
+    if days < 0 {
+        return nil, fmt.Errorf("%w%d", ErrInvalidDaysToSync, days)
+    }
+    end, err := time.Parse("2006-01-02", today)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrInvalidDaysToSync, err)
+    }
+    dates := make([]string, 0, days)
+    for i := days - 1; i >= 0; i-- {
+        dates = append(dates, end.AddDate(0, 0, -i).Format("2006-01-02"))
+    }
+    return dates, nil
+}
+
+func getEntriesGroupedByDate(cmd commandOutputter, from, to string, filter *EntryFilter) (map[string][]Entry, error) {
+    if filter != nil {
+        err := filter.validate()
+        if err != nil {
+            return nil, err
+        }
+    }
+    if source, ok := cmd.(dateRanger); ok {
+        source.SetDateRange(from, to)
+    }
+    pipe, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrJrnlCommandFailed, err)
+    }
+    err = cmd.Start()
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrJrnlCommandFailed, err)
+    }
+    groupByDate := make(map[string][]Entry)
+    decodeErr := decodeEntries(pipe, func(e Entry) {
+        if (from != "" && e.Date < from) || (to != "" && e.Date > to) {
+            return
+        }
+        if e, ok := filter.apply(e); ok {
+            groupByDate[e.Date] = append(groupByDate[e.Date], e)
+        }
+    })
+    if decodeErr != nil {
+        // drain what's left so jrnl isn't blocked writing it and can exit
+        _, _ = io.Copy(io.Discard, pipe)
+    }
+    err = cmd.Wait()
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrJrnlCommandFailed, err)
+    }
+    if decodeErr != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToUnmarshalJrnlOutput, decodeErr)
+    }
+
+    return groupByDate, nil
+}
+
+// decodeEntries hands each entry in jrnl's json export to fn as it's read, so
+// neither the export nor every entry in the journal has to be held in memory.
+func decodeEntries(jrnlOutput io.Reader, fn func(Entry)) error {
+    decoder := json.NewDecoder(jrnlOutput)
+    err := expectDelim(decoder, '{')
+    if err != nil {
+        return err
+    }
+    for decoder.More() {
+        key, err := decoder.Token()
+        if err != nil {
+            return err
+        }
+        if key != "entries" {
+            var skip json.RawMessage
+            err = decoder.Decode(&skip)
+            if err != nil {
+                return err
+            }
+            continue
+        }
+        token, err := decoder.Token()
+        if err != nil {
+            return err
+        }
+        if token == nil {
+            continue
+        }
+        if token != json.Delim('[') {
+            return fmt.Errorf("expected entries to be a list, got %v", token)
+        }
+        for decoder.More() {
+            e := Entry{}
+            err = decoder.Decode(&e)
+            if err != nil {
+                return err
+            }
+            fn(e)
+        }
+        err = expectDelim(decoder, ']')
+        if err != nil {
+            return err
+        }
+    }
+    return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+    token, err := decoder.Token()
+    if err != nil {
+        return err
+    }
+    if token != delim {
+        return fmt.Errorf("expected %s, got %v", delim, token)
+    }
+    return nil
+}
+
+func writeJrnlEntries(w io.Writer, entries []Entry) error {
+    for _, e := range entries {
+        title, body := e.Title, strings.TrimRight(e.Body, "\n")
+        if title == "" {
+            lines := strings.SplitN(body, "\n", 2)
+            title, body = lines[0], ""
+            if len(lines) == 2 {
+                body = lines[1]
+            }
+        }
+        header := fmt.Sprintf("[%s %s] %s", e.Date, e.Time, title)
+        if e.Starred {
+            header = fmt.Sprintf("%s *", header)
+        }
+        _, err := fmt.Fprintln(w, header)
+        if err != nil {
+            return err
+        }
+        if body != "" {
+            _, err = fmt.Fprintln(w, body)
+            if err != nil {
+                return err
+            }
+        }
+        _, err = fmt.Fprintln(w)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,124 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jm96441n/jrnlSync/jrnl"
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"gopkg.in/yaml.v2"
+)
+
+type SyncMapping struct {
+    Name string `yaml:"name"`
+    Journal string `yaml:"journal"`
+    Provider string `yaml:"provider"`
+    Flags map[string]string `yaml:"flags"`
+}
+
+type syncMappingsFile struct {
+    Syncs []SyncMapping `yaml:"syncs"`
+}
+
+type MultiSyncConfig struct {
+    ConfigFile string
+    Providers map[string]func(journal string) *ffcli.Command
+    Out io.Writer
+}
+
+var ErrFailedToReadSyncConfig = errors.New("failed to read the sync config: ")
+var ErrUnknownSyncProvider = errors.New("unknown sync provider: ")
+var ErrSyncMappingsFailed = errors.New("some syncs failed: ")
+
+func NewMultiSyncFlagSet(providers map[string]func(journal string) *ffcli.Command) *ffcli.Command {
+    c := &MultiSyncConfig{Providers: providers, Out: os.Stdout}
+    multiSyncFlagSet := flag.NewFlagSet("jrnlsync sync", flag.ExitOnError)
+    multiSyncFlagSet.StringVar(&c.ConfigFile, "c", "~/.config/jrnlSync/sync.yaml", "The file that maps your journals to where they should be synced")
+
+    return &ffcli.Command{
+        Name:       "sync",
+        ShortUsage: "jrnlSync sync -c [SYNC_CONFIG_FILE]",
+        ShortHelp:  "Syncs every journal in your sync config to its destination",
+        FlagSet:    multiSyncFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *MultiSyncConfig) Exec(ctx context.Context, _ []string) error {
+    mappings, err := loadSyncMappings(c.ConfigFile)
+    if err != nil {
+        return err
+    }
+
+    failed := 0
+    for _, mapping := range mappings {
+        err := c.run(ctx, mapping)
+        if err != nil {
+            failed++
+            fmt.Fprintf(c.Out, "%s: failed: %s\n", mapping.label(), err)
+            continue
+        }
+        fmt.Fprintf(c.Out, "%s: ok\n", mapping.label())
+    }
+    if failed > 0 {
+        return fmt.Errorf("%w%d of %d", ErrSyncMappingsFailed, failed, len(mappings))
+    }
+    return nil
+}
+
+func (c *MultiSyncConfig) run(ctx context.Context, mapping SyncMapping) error {
+    newCommand, ok := c.Providers[mapping.Provider]
+    if !ok {
+        return fmt.Errorf("%w%q", ErrUnknownSyncProvider, mapping.Provider)
+    }
+    command := newCommand(mapping.Journal)
+    // A bad flag in one mapping shouldn't exit before the others get to run.
+    command.FlagSet.Init(command.FlagSet.Name(), flag.ContinueOnError)
+    command.FlagSet.SetOutput(c.Out)
+    return command.ParseAndRun(ctx, mapping.args())
+}
+
+func (m SyncMapping) label() string {
+    if m.Name != "" {
+        return m.Name
+    }
+    journal := m.Journal
+    if journal == "" {
+        journal = jrnl.DefaultJournalName
+    }
+    return fmt.Sprintf("%s -> %s", journal, m.Provider)
+}
+
+func (m SyncMapping) args() []string {
+    names := make([]string, 0, len(m.Flags))
+    for name := range m.Flags {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    args := make([]string, 0, len(names))
+    for _, name := range names {
+        args = append(args, fmt.Sprintf("-%s=%s", name, m.Flags[name]))
+    }
+    return args
+}
+
+func loadSyncMappings(path string) ([]SyncMapping, error) {
+    contents, err := os.ReadFile(jrnl.ExpandHome(path))
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToReadSyncConfig, err)
+    }
+    config := syncMappingsFile{}
+    err = yaml.UnmarshalStrict(contents, &config)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToReadSyncConfig, err)
+    }
+    if len(config.Syncs) == 0 {
+        return nil, fmt.Errorf("%wno syncs in %s", ErrFailedToReadSyncConfig, path)
+    }
+    return config.Syncs, nil
+}
@@ -0,0 +1,98 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+const syncMappings = `syncs:
+  - journal: work
+    provider: notion
+    flags:
+      d: work-db
+      k: fakeNotionKey
+  - name: personal backup
+    journal: personal
+    provider: obsidian
+  - name: typo
+    journal: personal
+    provider: notion
+    flags:
+      dbid: personal-db
+  - journal: personal
+    provider: notion
+    flags:
+      d: personal-db
+      k: fakeNotionKey
+`
+
+func TestMultiSyncRunsEveryMappingAndReportsFailuresIndependently(t *testing.T) {
+    configFile := filepath.Join(t.TempDir(), "sync.yaml")
+    err := os.WriteFile(configFile, []byte(syncMappings), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    notion := newFakeNotion()
+    journals := []string{}
+    out := &bytes.Buffer{}
+    config := sync.MultiSyncConfig{
+        ConfigFile: configFile,
+        Providers: map[string]func(journal string) *ffcli.Command{
+            "notion": func(journal string) *ffcli.Command {
+                journals = append(journals, journal)
+                return sync.NewNotionSyncFlagSet(notion, mockCommand{outputString: jrnlOutputFixture(t)}, "2021-11-24")
+            },
+        },
+        Out: out,
+    }
+    err = config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrSyncMappingsFailed) {
+        t.Errorf("Expected error to be of type ErrSyncMappingsFailed, got %+v", err)
+    }
+
+    expectedJournals := []string{"work", "personal", "personal"}
+    if !reflect.DeepEqual(expectedJournals, journals) {
+        t.Errorf("expected commands to be built for %v, got %v", expectedJournals, journals)
+    }
+    expectedOutput := []string{
+        "work -> notion: ok",
+        `personal backup: failed: unknown sync provider: "obsidian"`,
+        "typo: failed: ",
+        "personal -> notion: ok",
+    }
+    for _, expected := range expectedOutput {
+        if !strings.Contains(out.String(), expected) {
+            t.Errorf("expected output to contain %q, got %q", expected, out.String())
+        }
+    }
+    for _, dbID := range []string{"work-db", "personal-db"} {
+        found := false
+        for _, request := range notion.requests {
+            found = found || request == "POST /databases/"+dbID+"/query"
+        }
+        if !found {
+            t.Errorf("expected the %s database to be synced, got %v", dbID, notion.requests)
+        }
+    }
+}
+
+func TestMultiSyncReturnsErrWhenTheConfigIsMissing(t *testing.T) {
+    config := sync.MultiSyncConfig{
+        ConfigFile: filepath.Join(t.TempDir(), "sync.yaml"),
+        Out: &bytes.Buffer{},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrFailedToReadSyncConfig) {
+        t.Errorf("Expected error to be of type ErrFailedToReadSyncConfig, got %+v", err)
+    }
+}
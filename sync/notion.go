@@ -1,9 +1,20 @@
 package sync
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// notionDateProperty is the date property jrnlSync keeps the day (or range of
+// days for week and month pages) a page holds entries for in, so pages can be
+// found by date whatever their title.
+const notionDateProperty = "Journal date"
+
 type NotionDocument struct {
     Parent ParentInfo `json:"parent"`
     Properties NotionProperties `json:"properties"`
-    Children []BulletedListItem `json:"children"`
+    Children []Block `json:"children"`
 }
 
 type ParentInfo struct {
@@ -12,6 +23,16 @@ type ParentInfo struct {
 
 type NotionProperties struct {
     Name NotionName `json:"Name"`
+    Date *NotionDateProperty `json:"Journal date,omitempty"`
+}
+
+type NotionDateProperty struct {
+    Date *NotionDate `json:"date"`
+}
+
+type NotionDate struct {
+    Start string `json:"start"`
+    End string `json:"end,omitempty"`
 }
 
 type NotionName struct {
@@ -21,36 +42,127 @@ type NotionName struct {
 type NotionTitle struct {
     Text map[string]string `json:"text"`
     Type *string `json:"type,omitempty"`
+    PlainText string `json:"plain_text,omitempty"`
 }
 
-type BulletedListItem struct {
+type Block struct {
     Object string `json:"object"`
+    ID string `json:"id,omitempty"`
+    CreatedBy *NotionUser `json:"created_by,omitempty"`
     Type string `json:"type"`
-    BulletedList ListItem `json:"bulleted_list_item"`
+    BulletedList *ListItem `json:"bulleted_list_item,omitempty"`
+    NumberedList *ListItem `json:"numbered_list_item,omitempty"`
+    Paragraph *ListItem `json:"paragraph,omitempty"`
+    ToDo *ListItem `json:"to_do,omitempty"`
+    Toggle *ListItem `json:"toggle,omitempty"`
+    Quote *ListItem `json:"quote,omitempty"`
+    Callout *ListItem `json:"callout,omitempty"`
+    Heading1 *ListItem `json:"heading_1,omitempty"`
+    Heading2 *ListItem `json:"heading_2,omitempty"`
+    Heading3 *ListItem `json:"heading_3,omitempty"`
+}
+
+type NotionUser struct {
+    Object string `json:"object,omitempty"`
+    ID string `json:"id"`
 }
 
 type ListItem struct {
     Text []NotionTitle `json:"text"`
+    Icon *NotionIcon `json:"icon,omitempty"`
+    Children []Block `json:"children,omitempty"`
 }
 
-func newNotionDocument(entries []string, config *Config) NotionDocument {
-    children := make([]BulletedListItem, 0)
+type NotionIcon struct {
+    Type string `json:"type"`
+    Emoji string `json:"emoji"`
+}
 
-    txt := "text"
-    for _, e := range entries {
-        item := BulletedListItem{
-                Object: "block",
-                Type: "bulleted_list_item",
-                BulletedList: ListItem{
-                    Text: []NotionTitle{
-                        {
-                            Type: &txt,
-                            Text: map[string]string{"content": e},
-                        },
-                    },
-                },
-            }
-        children = append(children, item)
+type NotionPage struct {
+    Object string `json:"object"`
+    ID string `json:"id"`
+    Archived bool `json:"archived"`
+    Properties NotionProperties `json:"properties"`
+}
+
+type notionQuery struct {
+    Filter *notionFilter `json:"filter,omitempty"`
+    StartCursor string `json:"start_cursor,omitempty"`
+}
+
+type notionFilter struct {
+    Property string `json:"property"`
+    Title *notionTextFilter `json:"title,omitempty"`
+}
+
+type notionTextFilter struct {
+    Equals string `json:"equals"`
+}
+
+type notionBlockChildren struct {
+    Children []Block `json:"children"`
+    After string `json:"after,omitempty"`
+}
+
+type notionPageList struct {
+    Results []NotionPage `json:"results"`
+    HasMore bool `json:"has_more"`
+    NextCursor string `json:"next_cursor"`
+}
+
+type notionPageGroup struct {
+    Title string
+    Date string
+    To string
+    Entries []Entry
+}
+
+type notionDatabase struct {
+    Properties map[string]struct {
+        Type string `json:"type"`
+    } `json:"properties"`
+}
+
+type notionDatabaseUpdate struct {
+    Properties map[string]interface{} `json:"properties"`
+}
+
+type notionPageUpdate struct {
+    Properties notionDateProperties `json:"properties"`
+}
+
+type notionDateProperties struct {
+    Date *NotionDateProperty `json:"Journal date"`
+}
+
+type notionBlockList struct {
+    Results []Block `json:"results"`
+    HasMore bool `json:"has_more"`
+    NextCursor string `json:"next_cursor"`
+}
+
+func newNotionDocument(group notionPageGroup, config *Config) (NotionDocument, error) {
+    title := group.Title
+    if config.pageTemplate != nil {
+        var err error
+        title, err = config.pageTemplate.title(group, config.GroupBy)
+        if err != nil {
+            return NotionDocument{}, err
+        }
+    }
+
+    children := make([]Block, 0)
+    date := ""
+    for _, e := range group.Entries {
+        if (config.GroupBy == GroupByWeek || config.GroupBy == GroupByMonth) && e.Date != date {
+            date = e.Date
+            children = append(children, newTextBlock("heading_2", date))
+        }
+        blocks, err := newEntryBlocks(e, config)
+        if err != nil {
+            return NotionDocument{}, err
+        }
+        children = append(children, blocks...)
     }
 
     n := NotionDocument{
@@ -60,7 +172,7 @@ func newNotionDocument(entries []string, config *Config) NotionDocument {
                 Title: []NotionTitle{
                     {
                         Text: map[string]string{
-                            "content": config.DateForEntries,
+                            "content": title,
                         },
                     },
                 },
@@ -68,5 +180,169 @@ func newNotionDocument(entries []string, config *Config) NotionDocument {
         },
         Children: children,
     }
-    return n
+    if group.Date != "" {
+        n.Properties.Date = &NotionDateProperty{Date: &NotionDate{Start: group.Date}}
+        if group.To != "" && group.To != group.Date {
+            n.Properties.Date.Date.End = group.To
+        }
+    }
+    return n, nil
+}
+
+func newEntryBlocks(e Entry, config *Config) ([]Block, error) {
+    if config.pageTemplate != nil {
+        return config.pageTemplate.entryBlocks(e)
+    }
+    if config.GroupBy == GroupByEntry {
+        return newParagraphBlocks(entryText(e)), nil
+    }
+    return []Block{newTextBlock("bulleted_list_item", entryText(e))}, nil
+}
+
+// entryText is an entry the way jrnl writes it, less the date which is the page
+// or heading it's under, so restore can read the time, title and star back.
+func entryText(e Entry) string {
+    header := e.Title
+    if e.Time != "" {
+        header = strings.TrimSpace(fmt.Sprintf("[%s] %s", e.Time, header))
+    }
+    if e.Starred {
+        header = fmt.Sprintf("%s *", header)
+    }
+    body := strings.TrimSpace(e.Body)
+    switch {
+    case header == "":
+        return body
+    case body == "":
+        return header
+    }
+    return fmt.Sprintf("%s\n%s", header, body)
+}
+
+func newParagraphBlocks(text string) []Block {
+    blocks := make([]Block, 0)
+    for _, p := range strings.Split(strings.TrimSpace(text), "\n\n") {
+        if strings.TrimSpace(p) != "" {
+            blocks = append(blocks, newTextBlock("paragraph", strings.TrimSpace(p)))
+        }
+    }
+    return blocks
+}
+
+func newTextBlock(blockType, content string) Block {
+    txt := "text"
+    item := &ListItem{
+        Text: []NotionTitle{
+            {
+                Type: &txt,
+                Text: map[string]string{"content": content},
+            },
+        },
+    }
+    b := Block{Object: "block", Type: blockType}
+    switch blockType {
+    case "bulleted_list_item":
+        b.BulletedList = item
+    case "numbered_list_item":
+        b.NumberedList = item
+    case "to_do":
+        b.ToDo = item
+    case "toggle":
+        b.Toggle = item
+    case "quote":
+        b.Quote = item
+    case "callout":
+        b.Callout = item
+    case "heading_1":
+        b.Heading1 = item
+    case "heading_2":
+        b.Heading2 = item
+    case "heading_3":
+        b.Heading3 = item
+    default:
+        b.Type = "paragraph"
+        b.Paragraph = item
+    }
+    return b
+}
+
+func (b Block) PlainText() string {
+    var item *ListItem
+    for _, candidate := range []*ListItem{
+        b.BulletedList, b.NumberedList, b.Paragraph, b.ToDo, b.Toggle,
+        b.Quote, b.Callout, b.Heading1, b.Heading2, b.Heading3,
+    } {
+        if candidate != nil {
+            item = candidate
+            break
+        }
+    }
+    if item == nil {
+        return ""
+    }
+    return richTextPlainText(item.Text)
+}
+
+func (d NotionDocument) Title() string {
+    return richTextPlainText(d.Properties.Name.Title)
+}
+
+func (p NotionPage) Title() string {
+    return richTextPlainText(p.Properties.Name.Title)
+}
+
+// Dates are the first and last day a page has entries for, from its journal
+// date or, for a page synced before jrnlSync set one, a YYYY-MM-DD title. Pages
+// with neither aren't journal pages.
+func (p NotionPage) Dates() (string, string, bool) {
+    if p.Properties.Date != nil && p.Properties.Date.Date != nil {
+        // a date edited in notion can have a time on the end
+        from := dateOnly(p.Properties.Date.Date.Start)
+        to := dateOnly(p.Properties.Date.Date.End)
+        if to == "" {
+            to = from
+        }
+        if isEntryDate(from) && isEntryDate(to) {
+            return from, to, true
+        }
+    }
+    if title := p.Title(); isEntryDate(title) {
+        return title, title, true
+    }
+    return "", "", false
+}
+
+func dateOnly(date string) string {
+    if len(date) > len("2006-01-02") {
+        return date[:len("2006-01-02")]
+    }
+    return date
+}
+
+// datedSections splits a page into the blocks for each date on it, the part
+// before any date heading (all of a day or entry page) is the page's first day.
+func datedSections(page NotionPage, blocks []Block) []notionSection {
+    from, _, _ := page.Dates()
+    sections := splitSections(blocks, true)
+    if sections[0].Date == "" {
+        sections[0].Date = from
+    }
+    return sections
+}
+
+func isEntryDate(text string) bool {
+    _, err := time.Parse("2006-01-02", text)
+    return err == nil
+}
+
+func richTextPlainText(text []NotionTitle) string {
+    var s strings.Builder
+    for _, t := range text {
+        if t.PlainText != "" {
+            s.WriteString(t.PlainText)
+            continue
+        }
+        s.WriteString(t.Text["content"])
+    }
+    return s.String()
 }
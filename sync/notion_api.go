@@ -0,0 +1,175 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const notionBaseAddress = "https://api.notion.com/v1"
+const notionMaxBlocksPerRequest = 100
+
+type httpInteractor interface {
+    Do(*http.Request) (*http.Response, error)
+}
+
+type notionAPI struct {
+    key string
+    httpClient httpInteractor
+}
+
+var ErrPostingToNotion = errors.New("internal error making request to notion: ")
+var ErrHTTPStatus = errors.New("posting to notion failed with status code: ")
+var ErrFailedToDecodeNotionResponse = errors.New("failed to decode notion response: ")
+
+func (n notionAPI) createPage(notionDocument NotionDocument) error {
+    children := notionDocument.Children
+    if len(children) > notionMaxBlocksPerRequest {
+        notionDocument.Children = children[:notionMaxBlocksPerRequest]
+    }
+    page := NotionPage{}
+    err := n.request("POST", "/pages", notionDocument, &page)
+    if err != nil {
+        return err
+    }
+    return n.appendBlocks(page.ID, children[len(notionDocument.Children):])
+}
+
+func (n notionAPI) appendBlocks(blockID string, blocks []Block) error {
+    return n.insertBlocks(blockID, "", blocks)
+}
+
+// insertBlocks adds blocks to the page or block blockID straight after the
+// child block after, or at the end when after is empty.
+func (n notionAPI) insertBlocks(blockID, after string, blocks []Block) error {
+    for start := 0; start < len(blocks); start += notionMaxBlocksPerRequest {
+        end := start + notionMaxBlocksPerRequest
+        if end > len(blocks) {
+            end = len(blocks)
+        }
+        path := fmt.Sprintf("/blocks/%s/children", url.PathEscape(blockID))
+        added := notionBlockList{}
+        err := n.request("PATCH", path, notionBlockChildren{Children: blocks[start:end], After: after}, &added)
+        if err != nil {
+            return err
+        }
+        // the next lot goes after the last of this one
+        if after != "" && len(added.Results) > 0 {
+            after = added.Results[len(added.Results)-1].ID
+        }
+    }
+    return nil
+}
+
+func (n notionAPI) deleteBlock(blockID string) error {
+    return n.request("DELETE", fmt.Sprintf("/blocks/%s", url.PathEscape(blockID)), nil, nil)
+}
+
+// botUserID is the user notion records as having written the blocks jrnlSync
+// adds, telling them apart from ones written in notion itself.
+func (n notionAPI) botUserID() (string, error) {
+    bot := NotionUser{}
+    err := n.request("GET", "/users/me", nil, &bot)
+    return bot.ID, err
+}
+
+func (n notionAPI) database(dbID string) (notionDatabase, error) {
+    database := notionDatabase{}
+    err := n.request("GET", fmt.Sprintf("/databases/%s", url.PathEscape(dbID)), nil, &database)
+    return database, err
+}
+
+func (n notionAPI) addDateProperty(dbID, name string) error {
+    update := notionDatabaseUpdate{Properties: map[string]interface{}{name: map[string]interface{}{"date": struct{}{}}}}
+    return n.request("PATCH", fmt.Sprintf("/databases/%s", url.PathEscape(dbID)), update, nil)
+}
+
+func (n notionAPI) updatePageDate(pageID string, date *NotionDateProperty) error {
+    update := notionPageUpdate{Properties: notionDateProperties{Date: date}}
+    return n.request("PATCH", fmt.Sprintf("/pages/%s", url.PathEscape(pageID)), update, nil)
+}
+
+func (n notionAPI) findPagesByTitle(dbID, title string) ([]NotionPage, error) {
+    return n.queryDatabase(dbID, &notionFilter{Property: "Name", Title: &notionTextFilter{Equals: title}})
+}
+
+func (n notionAPI) queryDatabase(dbID string, filter *notionFilter) ([]NotionPage, error) {
+    pages := make([]NotionPage, 0)
+    query := notionQuery{Filter: filter}
+    for {
+        resp := notionPageList{}
+        err := n.request("POST", fmt.Sprintf("/databases/%s/query", url.PathEscape(dbID)), query, &resp)
+        if err != nil {
+            return nil, err
+        }
+        pages = append(pages, resp.Results...)
+        if !resp.HasMore || resp.NextCursor == "" {
+            return pages, nil
+        }
+        query.StartCursor = resp.NextCursor
+    }
+}
+
+func (n notionAPI) blockChildren(blockID string) ([]Block, error) {
+    blocks := make([]Block, 0)
+    cursor := ""
+    for {
+        path := fmt.Sprintf("/blocks/%s/children?page_size=100", url.PathEscape(blockID))
+        if cursor != "" {
+            path = fmt.Sprintf("%s&start_cursor=%s", path, url.QueryEscape(cursor))
+        }
+        resp := notionBlockList{}
+        err := n.request("GET", path, nil, &resp)
+        if err != nil {
+            return nil, err
+        }
+        blocks = append(blocks, resp.Results...)
+        if !resp.HasMore || resp.NextCursor == "" {
+            return blocks, nil
+        }
+        cursor = resp.NextCursor
+    }
+}
+
+func (n notionAPI) request(method, path string, body interface{}, out interface{}) error {
+    buf := &bytes.Buffer{}
+    if body != nil {
+        jsonBytes, err := json.Marshal(body)
+        if err != nil {
+            return err
+        }
+        buf = bytes.NewBuffer(jsonBytes)
+    }
+
+    req, err := http.NewRequest(method, fmt.Sprintf("%s%s", notionBaseAddress, path), buf)
+    if err != nil {
+        return err
+    }
+
+    req.Header = http.Header{
+        "Content-Type": []string{"application/json"},
+        "Authorization": []string{fmt.Sprintf("Bearer %s", n.key)},
+        "Notion-Version": []string{"2021-08-16"},
+    }
+
+    res, err := n.httpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrPostingToNotion, err)
+    }
+    defer res.Body.Close()
+
+    if res.StatusCode > 299 {
+        return fmt.Errorf("%w%s", ErrHTTPStatus, res.Status)
+    }
+    if out == nil {
+        return nil
+    }
+    err = json.NewDecoder(res.Body).Decode(out)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToDecodeNotionResponse, err)
+    }
+    return nil
+}
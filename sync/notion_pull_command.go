@@ -0,0 +1,215 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type PullConfig struct {
+    DBID string
+    NotionKey string
+    Profile NotionProfile
+    HttpClient httpInteractor
+    Cmd commandOutputter
+    Importer jrnlImporter
+    From string
+    To string
+    DefaultTime string
+    GroupBy string
+    Template string
+    Filter EntryFilter
+    Redact Redactor
+    Out io.Writer
+}
+
+func NewNotionPullFlagSet(httpClient httpInteractor, cmd commandOutputter, importer jrnlImporter) *ffcli.Command {
+    c := &PullConfig{HttpClient: httpClient, Cmd: cmd, Importer: importer, Out: os.Stdout}
+    pullFlagSet := flag.NewFlagSet("jrnlsync pull", flag.ExitOnError)
+    pullFlagSet.StringVar(&c.DBID, "d", "", "The id of the notion database to pull the daily journal pages from")
+    pullFlagSet.StringVar(&c.NotionKey, "k", "", "Your notion integration key")
+    c.Profile.RegisterFlags(pullFlagSet)
+    pullFlagSet.StringVar(&c.From, "from", "", "Only pull pages for dates on or after this date (YYYY-MM-DD)")
+    pullFlagSet.StringVar(&c.To, "to", "", "Only pull pages for dates on or before this date (YYYY-MM-DD)")
+    pullFlagSet.StringVar(&c.DefaultTime, "time", "09:00", "The time (HH:MM) to give entries pulled from notion")
+    registerLayoutFlags(pullFlagSet, &c.GroupBy, &c.Template)
+    c.Filter.RegisterFlags(pullFlagSet)
+    c.Redact.RegisterFlags(pullFlagSet)
+    registerSourceFlags(pullFlagSet, cmd)
+
+    return &ffcli.Command{
+        Name:       "pull",
+        ShortUsage: "jrnlSync pull -d [DATABASE_ID] -k [NOTION_INTEGRATION_KEY]",
+        ShortHelp:  "Adds notes written in your notion database that aren't in jrnl yet to your journal",
+        FlagSet:    pullFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *PullConfig) Exec(_ context.Context, _ []string) error {
+    err := c.Profile.fill(&c.DBID, &c.NotionKey)
+    if err != nil {
+        return err
+    }
+    layout := &Config{DBID: c.DBID, GroupBy: c.GroupBy, Template: c.Template}
+    err = layout.loadTemplate()
+    if err != nil {
+        return err
+    }
+    err = c.Filter.validate()
+    if err != nil {
+        return err
+    }
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, c.From, c.To, nil)
+    if err != nil {
+        return err
+    }
+    entriesGroupedByDate, err = c.sentEntries(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+
+    api := notionAPI{key: c.NotionKey, httpClient: c.HttpClient}
+    pages, err := api.queryDatabase(c.DBID, nil)
+    if err != nil {
+        return err
+    }
+    sort.SliceStable(pages, func(i, j int) bool {
+        from, _, _ := pages[i].Dates()
+        other, _, _ := pages[j].Dates()
+        return from < other
+    })
+
+    newEntries := make([]Entry, 0)
+    for _, page := range pages {
+        from, to, ok := page.Dates()
+        if !ok || !c.overlaps(from, to) || page.Archived {
+            continue
+        }
+        blocks, err := api.blockChildren(page.ID)
+        if err != nil {
+            return err
+        }
+
+        for _, section := range datedSections(page, blocks) {
+            if !c.wantsDate(section.Date) {
+                continue
+            }
+            known, err := knownBlockTexts(entriesGroupedByDate[section.Date], layout)
+            if err != nil {
+                return err
+            }
+            pulled := 0
+            for _, b := range section.Blocks {
+                text := strings.TrimSpace(b.PlainText())
+                if text == "" || known[normalizeEntryText(text)] || c.Filter.isPlaceholder(text) {
+                    continue
+                }
+                known[normalizeEntryText(text)] = true
+                newEntries = append(newEntries, Entry{Date: section.Date, Time: c.DefaultTime, Body: text})
+                pulled++
+            }
+            if pulled > 0 {
+                fmt.Fprintf(c.out(), "%s: %d new entries\n", section.Date, pulled)
+            }
+        }
+    }
+
+    if len(newEntries) == 0 {
+        fmt.Fprint(c.out(), "Nothing to pull, jrnl is up to date with notion\n")
+        return nil
+    }
+
+    buf := &bytes.Buffer{}
+    err = writeJrnlEntries(buf, newEntries)
+    if err != nil {
+        return err
+    }
+    // entries go back into the journal -journal and -jrnl-config picked
+    importArgs := []string{"--import"}
+    if source, ok := c.Cmd.(importArger); ok {
+        importArgs = source.ImportArgs()
+    }
+    err = c.Importer.Import(importArgs, buf)
+    if err != nil {
+        return err
+    }
+    fmt.Fprintf(c.out(), "Imported %d entries into jrnl\n", len(newEntries))
+    return nil
+}
+
+// sentEntries adds the entries as sync sent them, filtered (a private entry's
+// placeholder) and redacted, to the ones in jrnl so neither is pulled back in.
+func (c *PullConfig) sentEntries(entriesGroupedByDate map[string][]Entry) (map[string][]Entry, error) {
+    sent := make(map[string][]Entry)
+    for date, entries := range entriesGroupedByDate {
+        for _, e := range entries {
+            if e, ok := c.Filter.apply(e); ok {
+                sent[date] = append(sent[date], e)
+            }
+        }
+    }
+    err := c.Redact.apply(sent)
+    if err != nil {
+        return nil, err
+    }
+    for date, entries := range entriesGroupedByDate {
+        sent[date] = append(sent[date], entries...)
+    }
+    return sent, nil
+}
+
+func (c *PullConfig) wantsDate(date string) bool {
+    if !isEntryDate(date) {
+        return false
+    }
+    return (c.From == "" || date >= c.From) && (c.To == "" || date <= c.To)
+}
+
+func (c *PullConfig) overlaps(from, to string) bool {
+    return (c.From == "" || to >= c.From) && (c.To == "" || from <= c.To)
+}
+
+func (c *PullConfig) out() io.Writer {
+    if c.Out == nil {
+        return io.Discard
+    }
+    return c.Out
+}
+
+func knownEntryTexts(entries []Entry) map[string]bool {
+    known := make(map[string]bool)
+    for _, e := range entries {
+        known[normalizeEntryText(e.Body)] = true
+        known[normalizeEntryText(e.Title)] = true
+        known[normalizeEntryText(fmt.Sprintf("%s %s", e.Title, e.Body))] = true
+    }
+    return known
+}
+
+// knownBlockTexts adds the blocks sync lays the entries out as, so the headings
+// and tags a template puts around an entry aren't pulled in as new ones
+func knownBlockTexts(entries []Entry, layout *Config) (map[string]bool, error) {
+    known := knownEntryTexts(entries)
+    for _, e := range entries {
+        blocks, err := newEntryBlocks(e, layout)
+        if err != nil {
+            return nil, err
+        }
+        for _, b := range blocks {
+            known[normalizeEntryText(b.PlainText())] = true
+        }
+    }
+    return known, nil
+}
+
+func normalizeEntryText(text string) string {
+    return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,520 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+func TestPullImportsBlocksThatAreNotInJrnlYet(t *testing.T) {
+    notion := newFakeNotion()
+    notion.pageSize = 1
+    notion.addPage("2021-11-23", "Too early")
+    notion.addPage("2021-11-24", "The new one", "Written on my phone", "next one")
+    notion.addPage("not a date", "Should be ignored")
+
+    importer := &mockImporter{}
+    out := &bytes.Buffer{}
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        Importer: importer,
+        DefaultTime: "09:00",
+        Out: out,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedImport := "[2021-11-24 09:00] Written on my phone\n\n"
+    if importer.imported != expectedImport {
+        t.Errorf("expected to import %q, got %q", expectedImport, importer.imported)
+    }
+    if !strings.Contains(out.String(), "Imported 1 entries") {
+        t.Errorf("expected a summary of the import, got %q", out.String())
+    }
+}
+
+func TestPullOnlyPullsPagesInTheDateRange(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-20", "Outside the range")
+    notion.addPage("2021-11-24", "Inside the range")
+
+    importer := &mockImporter{}
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: `{"entries": []}`},
+        Importer: importer,
+        From: "2021-11-21",
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if importer.imported != "[2021-11-24 09:00] Inside the range\n\n" {
+        t.Errorf("expected only the page inside the range to be imported, got %q", importer.imported)
+    }
+}
+
+func TestPullDoesNotImportWhenJrnlIsUpToDate(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "The new one", "next one", "the last one")
+
+    importer := &mockImporter{}
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        Importer: importer,
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if importer.called {
+        t.Errorf("expected nothing to be imported, got %q", importer.imported)
+    }
+}
+
+func TestPullReadsWeekPagesLaidOutWithATemplate(t *testing.T) {
+    notion := newFakeNotion()
+    templateFile := writePageTemplate(t)
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: weekJrnlOutput},
+        DateForEntries: "2021-11-24",
+        GroupBy: sync.GroupByWeek,
+        Template: templateFile,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // a note written on the monday, after that day's entry
+    page := notion.pages[0]
+    phone := newFakeNotion().addPage("", "Written on my phone").blocks[0]
+    page.blocks = append(page.blocks[:3], append([]sync.Block{phone}, page.blocks[3:]...)...)
+
+    importer := &mockImporter{}
+    pull := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: weekJrnlOutput},
+        Importer: importer,
+        DefaultTime: "09:00",
+        GroupBy: sync.GroupByWeek,
+        Template: templateFile,
+    }
+    err = pull.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedImport := "[2021-11-22 09:00] Written on my phone\n\n"
+    if importer.imported != expectedImport {
+        t.Errorf("expected to import %q, got %q", expectedImport, importer.imported)
+    }
+}
+
+func TestPullSkipsThePlaceholderSyncWroteForPrivateEntries(t *testing.T) {
+    notion := newFakeNotion()
+    filter := sync.EntryFilter{PrivateTag: "@private", PrivateAction: sync.PrivateActionPlaceholder, Placeholder: "Private entry"}
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: filterJrnlOutput},
+        DateForEntries: "2021-11-24",
+        Filter: filter,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    page := notion.pages[0]
+    page.blocks = append(page.blocks, newFakeNotion().addPage("", "Written on my phone", "Private entry").blocks...)
+
+    importer := &mockImporter{}
+    pull := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: filterJrnlOutput},
+        Importer: importer,
+        DefaultTime: "09:00",
+        Filter: filter,
+    }
+    err = pull.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedImport := "[2021-11-24 09:00] Written on my phone\n\n"
+    if importer.imported != expectedImport {
+        t.Errorf("expected only the note written in notion to be imported, got %q", importer.imported)
+    }
+}
+
+func TestPullSkipsTheRedactedCopiesSyncWrote(t *testing.T) {
+    output := `{"tags": {}, "entries": [
+        {"title": "Call.", "body": "Rang jane@example.com back.", "date": "2021-11-24", "time": "09:00"}
+    ]}`
+    redact := sync.Redactor{Builtins: []string{"email"}, Action: sync.RedactActionHash, HashKey: "not-so-secret", Out: &bytes.Buffer{}}
+    notion := newFakeNotion()
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        DateForEntries: "2021-11-24",
+        Redact: redact,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    importer := &mockImporter{}
+    pull := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        Importer: importer,
+        DefaultTime: "09:00",
+        Redact: redact,
+    }
+    err = pull.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if importer.called {
+        t.Errorf("expected the redacted entry not to be imported, got %q", importer.imported)
+    }
+}
+
+func TestPullImportsIntoTheJournalItReadFrom(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "Written on my phone")
+
+    importer := &mockImporter{}
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockJournalCommand{mockCommand: mockCommand{outputString: jrnlOutputFixture(t)}, journal: "work"},
+        Importer: importer,
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual([]string{"work", "--import"}, importer.args) {
+        t.Errorf("expected jrnl to import into the work journal, got %v", importer.args)
+    }
+}
+
+func TestPullReturnsErrWhenImportFails(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "Written on my phone")
+
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        Importer: &mockImporter{errOnImport: true},
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrJrnlImportFailed) {
+        t.Errorf("Expected error to be of type ErrJrnlImportFailed, got %+v", err)
+    }
+}
+
+func TestPullReturnsErrWhenNotionRespondsWithAFailureStatusCode(t *testing.T) {
+    config := sync.PullConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: &mockHTTPClient{statusCode: 401},
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        Importer: &mockImporter{},
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrHTTPStatus) {
+        t.Errorf("Expected error to be of type ErrHTTPStatus, got %+v", err)
+    }
+}
+
+type mockImporter struct {
+    called bool
+    imported string
+    args []string
+    errOnImport bool
+}
+
+func (m *mockImporter) Import(args []string, r io.Reader) error {
+    m.called = true
+    m.args = args
+    if m.errOnImport {
+        return fmt.Errorf("%w%s", sync.ErrJrnlImportFailed, "error")
+    }
+    in, err := io.ReadAll(r)
+    if err != nil {
+        return err
+    }
+    m.imported = string(in)
+    return nil
+}
+
+type mockJournalCommand struct {
+    mockCommand
+    journal string
+}
+
+func (m mockJournalCommand) ImportArgs() []string {
+    return []string{m.journal, "--import"}
+}
+
+const fakeNotionBotID = "jrnlsync-bot"
+
+type fakeNotionPage struct {
+    page sync.NotionPage
+    blocks []sync.Block
+    added int
+}
+
+type fakeNotion struct {
+    pages []*fakeNotionPage
+    pageSize int
+    requests []string
+    properties map[string]map[string]interface{}
+}
+
+func newFakeNotion() *fakeNotion {
+    return &fakeNotion{pageSize: 100, properties: map[string]map[string]interface{}{"Name": {"type": "title"}}}
+}
+
+func (f *fakeNotion) addPage(title string, blockTexts ...string) *fakeNotionPage {
+    txt := "text"
+    page := &fakeNotionPage{
+        page: sync.NotionPage{
+            Object: "page",
+            ID: fmt.Sprintf("page-%d", len(f.pages)+1),
+            Properties: sync.NotionProperties{
+                Name: sync.NotionName{
+                    Title: []sync.NotionTitle{{Type: &txt, Text: map[string]string{"content": title}, PlainText: title}},
+                },
+            },
+        },
+    }
+    // pages named after a date were synced by jrnlSync, which dates them
+    if _, err := time.Parse("2006-01-02", title); err == nil {
+        page.page.Properties.Date = &sync.NotionDateProperty{Date: &sync.NotionDate{Start: title}}
+    }
+    for i, text := range blockTexts {
+        page.blocks = append(page.blocks, sync.Block{
+            Object: "block",
+            ID: fmt.Sprintf("%s-block-%d", page.page.ID, i+1),
+            Type: "bulleted_list_item",
+            BulletedList: &sync.ListItem{
+                Text: []sync.NotionTitle{{Type: &txt, Text: map[string]string{"content": text}, PlainText: text}},
+            },
+        })
+    }
+    f.pages = append(f.pages, page)
+    return page
+}
+
+// newBlocks gives blocks added to the page ids, like notion does
+func (p *fakeNotionPage) newBlocks(blocks []sync.Block) []sync.Block {
+    added := make([]sync.Block, 0, len(blocks))
+    for _, b := range blocks {
+        p.added++
+        b.ID = fmt.Sprintf("%s-new-%d", p.page.ID, p.added)
+        b.CreatedBy = &sync.NotionUser{Object: "user", ID: fakeNotionBotID}
+        added = append(added, b)
+    }
+    return added
+}
+
+func (f *fakeNotion) Do(req *http.Request) (*http.Response, error) {
+    path := strings.TrimPrefix(req.URL.Path, "/v1")
+    f.requests = append(f.requests, fmt.Sprintf("%s %s", req.Method, path))
+
+    body := []byte{}
+    if req.Body != nil {
+        var err error
+        body, err = io.ReadAll(req.Body)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    switch {
+    case req.Method == "POST" && strings.HasPrefix(path, "/databases/") && strings.HasSuffix(path, "/query"):
+        query := struct {
+            StartCursor string `json:"start_cursor"`
+            Filter *struct {
+                Title struct {
+                    Equals string `json:"equals"`
+                } `json:"title"`
+            } `json:"filter"`
+        }{}
+        if err := json.Unmarshal(body, &query); err != nil {
+            return nil, err
+        }
+        pages := make([]sync.NotionPage, 0, len(f.pages))
+        for _, p := range f.pages {
+            if query.Filter == nil || p.page.Title() == query.Filter.Title.Equals {
+                pages = append(pages, p.page)
+            }
+        }
+        return f.paginate(pages, query.StartCursor)
+    case req.Method == "GET" && strings.HasPrefix(path, "/blocks/") && strings.HasSuffix(path, "/children"):
+        id := strings.TrimSuffix(strings.TrimPrefix(path, "/blocks/"), "/children")
+        for _, p := range f.pages {
+            if p.page.ID == id {
+                return f.paginate(p.blocks, req.URL.Query().Get("start_cursor"))
+            }
+        }
+    case req.Method == "PATCH" && strings.HasPrefix(path, "/blocks/") && strings.HasSuffix(path, "/children"):
+        id := strings.TrimSuffix(strings.TrimPrefix(path, "/blocks/"), "/children")
+        children := struct {
+            Children []sync.Block `json:"children"`
+            After string `json:"after"`
+        }{}
+        if err := json.Unmarshal(body, &children); err != nil {
+            return nil, err
+        }
+        for _, p := range f.pages {
+            if p.page.ID == id {
+                added := p.newBlocks(children.Children)
+                at := len(p.blocks)
+                for i, b := range p.blocks {
+                    if children.After != "" && b.ID == children.After {
+                        at = i + 1
+                    }
+                }
+                p.blocks = append(p.blocks[:at], append(added, p.blocks[at:]...)...)
+                return f.paginate(added, "")
+            }
+        }
+    case req.Method == "GET" && strings.HasPrefix(path, "/databases/"):
+        return f.respond(200, map[string]interface{}{"object": "database", "properties": f.properties})
+    case req.Method == "PATCH" && strings.HasPrefix(path, "/databases/"):
+        update := struct {
+            Properties map[string]map[string]interface{} `json:"properties"`
+        }{}
+        if err := json.Unmarshal(body, &update); err != nil {
+            return nil, err
+        }
+        for name, property := range update.Properties {
+            for propertyType := range property {
+                f.properties[name] = map[string]interface{}{"type": propertyType}
+            }
+        }
+        return f.respond(200, map[string]interface{}{"object": "database", "properties": f.properties})
+    case req.Method == "PATCH" && strings.HasPrefix(path, "/pages/"):
+        update := sync.NotionPage{}
+        if err := json.Unmarshal(body, &update); err != nil {
+            return nil, err
+        }
+        for _, p := range f.pages {
+            if p.page.ID == strings.TrimPrefix(path, "/pages/") {
+                p.page.Properties.Date = update.Properties.Date
+                return f.respond(200, p.page)
+            }
+        }
+    case req.Method == "GET" && path == "/users/me":
+        return f.respond(200, sync.NotionUser{Object: "user", ID: fakeNotionBotID})
+    case req.Method == "DELETE" && strings.HasPrefix(path, "/blocks/"):
+        id := strings.TrimPrefix(path, "/blocks/")
+        for _, p := range f.pages {
+            for i, b := range p.blocks {
+                if b.ID == id {
+                    p.blocks = append(p.blocks[:i], p.blocks[i+1:]...)
+                    return f.respond(200, b)
+                }
+            }
+        }
+    case req.Method == "POST" && path == "/pages":
+        doc := sync.NotionDocument{}
+        if err := json.Unmarshal(body, &doc); err != nil {
+            return nil, err
+        }
+        title := doc.Properties.Name.Title[0].Text["content"]
+        if doc.Properties.Date != nil && f.properties["Journal date"] == nil {
+            return f.respond(400, map[string]string{"object": "error", "message": "Journal date is not a property that exists."})
+        }
+        page := f.addPage(title)
+        page.page.Properties.Date = doc.Properties.Date
+        page.blocks = page.newBlocks(doc.Children)
+        return f.respond(200, page.page)
+    }
+    return f.respond(404, map[string]string{"object": "error"})
+}
+
+func (f *fakeNotion) paginate(results interface{}, cursor string) (*http.Response, error) {
+    all, err := json.Marshal(results)
+    if err != nil {
+        return nil, err
+    }
+    items := []json.RawMessage{}
+    if err := json.Unmarshal(all, &items); err != nil {
+        return nil, err
+    }
+    start := 0
+    if cursor != "" {
+        fmt.Sscanf(cursor, "%d", &start)
+    }
+    end := start + f.pageSize
+    if end > len(items) {
+        end = len(items)
+    }
+    resp := map[string]interface{}{
+        "object": "list",
+        "results": items[start:end],
+        "has_more": end < len(items),
+        "next_cursor": nil,
+    }
+    if end < len(items) {
+        resp["next_cursor"] = fmt.Sprintf("%d", end)
+    }
+    return f.respond(200, resp)
+}
+
+func (f *fakeNotion) respond(statusCode int, body interface{}) (*http.Response, error) {
+    respBody, err := json.Marshal(body)
+    if err != nil {
+        return nil, err
+    }
+    return &http.Response{
+        Status: fmt.Sprintf("%d", statusCode),
+        StatusCode: statusCode,
+        Body: io.NopCloser(bytes.NewBuffer(respBody)),
+    }, nil
+}
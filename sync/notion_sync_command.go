@@ -1,55 +1,95 @@
 package sync
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"net/http"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/peterbourgon/ff/v3/ffcli"
 )
 
-const notionBaseAddress = "https://api.notion.com/v1"
-
 type Config struct {
     DBID string
     NotionKey string
+    Profile NotionProfile
     HttpClient httpInteractor
     Cmd commandOutputter
+    Filter EntryFilter
+    Redact Redactor
     DateForEntries string
+    Days int
+    Today string
+    GroupBy string
+    Template string
+    DryRun bool
+    Out io.Writer
+    pageTemplate *notionTemplate
+    botUserID string
+    hasDateProperty bool
 }
 
-type commandOutputter interface {
-    Output() ([]byte, error)
-}
+const (
+    GroupByDay = "day"
+    GroupByEntry = "entry"
+    GroupByWeek = "week"
+    GroupByMonth = "month"
+)
 
-type httpInteractor interface {
-    Do(*http.Request) (*http.Response, error)
-}
+var ErrUnknownGroupBy = errors.New("unknown group-by, use one of day, entry, week or month: ")
+var ErrInvalidDateForEntries = errors.New("invalid date for entries: ")
+var ErrDatePropertyConflict = errors.New("the notion database already has a property that isn't a date named: ")
 
-type JrnlBody struct {
-    Entries []Entry `json:"entries"`
+type notionPeriod struct {
+    Title string
+    From string
+    Date string
+    To string
 }
 
-type Entry struct {
-    Body string `json:"body"`
-    Date string `json:"date"`
+type notionPlan struct {
+    Action string
+    PageID string
+    Document NotionDocument
+    Existing []Block
+    Inserts []notionInsert
+    Delete []string
+    SetDate bool
 }
 
-var ErrJrnlCommandFailed = errors.New("the command to get output from jrnl failed with: ")
-var ErrFailedToUnmarshalJrnlOutput = errors.New("failed to unmarshal jrnl output: ")
+type notionInsert struct {
+    After string
+    Blocks []Block
+}
 
-var ErrPostingToNotion = errors.New("internal error making request to notion: ")
-var ErrHTTPStatus = errors.New("posting to notion failed with status code: ")
+// notionSection is the blocks for one date on a page. Week and month pages have
+// a heading_2 of the date before each date's entries, anything before the first
+// of them (or the whole of a day or entry page) is the section with no date.
+type notionSection struct {
+    Date string
+    Heading *Block
+    Blocks []Block
+}
 
 func NewNotionSyncFlagSet(httpClient httpInteractor, cmd commandOutputter, dateForentries string) *ffcli.Command {
-    c := &Config{HttpClient: httpClient, Cmd: cmd, DateForEntries: dateForentries}
+    c := &Config{HttpClient: httpClient, Cmd: cmd, DateForEntries: dateForentries, Today: time.Now().Format("2006-01-02"), Out: os.Stdout}
     syncFlagSet := flag.NewFlagSet("jrnlsync notion", flag.ExitOnError)
     syncFlagSet.StringVar(&c.DBID, "d", "", "The id of the notion database to put the daily journal page")
     syncFlagSet.StringVar(&c.NotionKey, "k", "", "Your notion integration key")
+    c.Profile.RegisterFlags(syncFlagSet)
+    registerLayoutFlags(syncFlagSet, &c.GroupBy, &c.Template)
+    syncFlagSet.BoolVar(&c.DryRun, "dry-run", false, "Print what would be sent to notion without changing anything")
+    syncFlagSet.StringVar(&c.DateForEntries, "date", dateForentries, "Sync the entries from this date (YYYY-MM-DD) instead of yesterday")
+    syncFlagSet.IntVar(&c.Days, "days", 0, "Sync each date with entries in the last N days, up to and including today, instead of one date")
+    c.Filter.RegisterFlags(syncFlagSet)
+    c.Redact.RegisterFlags(syncFlagSet)
+    registerSourceFlags(syncFlagSet, cmd)
 
 
     return &ffcli.Command{
@@ -61,64 +101,456 @@ func NewNotionSyncFlagSet(httpClient httpInteractor, cmd commandOutputter, dateF
     }
 }
 
+// registerLayoutFlags are the flags for how pages are laid out, commands that
+// read pages back take the same ones so they see pages the way sync wrote them
+func registerLayoutFlags(fs *flag.FlagSet, groupBy, template *string) {
+    fs.StringVar(groupBy, "group-by", GroupByDay, "Make one page per day, entry, week or month")
+    fs.StringVar(template, "template", "", "A go text/template file with \"title\" and \"entry\" templates for laying out pages")
+}
+
 func (c *Config) Exec(_ context.Context, _ []string) error {
-    entriesGroupedByDate, err := c.getEntriesGroupedByDate()
+    err := c.Profile.fill(&c.DBID, &c.NotionKey)
+    if err != nil {
+        return err
+    }
+    periods, err := c.periods()
     if err != nil {
         return err
     }
-    notionDocument := newNotionDocument(entriesGroupedByDate[c.DateForEntries], c)
-    err = c.postToNotion(notionDocument)
+    err = c.loadTemplate()
     if err != nil {
         return err
     }
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, periods[0].From, periods[len(periods)-1].Date, &c.Filter)
+    if err != nil {
+        return err
+    }
+    err = c.Redact.apply(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+
+    groups := make([]notionPageGroup, 0)
+    for _, period := range periods {
+        for _, group := range c.pageGroups(period, entriesGroupedByDate) {
+            // a range of days only makes pages for the days that have entries
+            if c.Days > 0 && len(group.Entries) == 0 {
+                continue
+            }
+            groups = append(groups, group)
+        }
+    }
+
+    api := notionAPI{key: c.NotionKey, httpClient: c.HttpClient}
+    for _, group := range groups {
+        document, err := newNotionDocument(group, c)
+        if err != nil {
+            return err
+        }
+        plan, err := c.planSync(api, document)
+        if err != nil {
+            return err
+        }
+        if c.DryRun {
+            err = c.printPlan(plan)
+        } else {
+            err = c.applyPlan(api, plan)
+        }
+        if err != nil {
+            return err
+        }
+    }
     return nil
 }
 
-func (c *Config) getEntriesGroupedByDate() (map[string][]string, error) {
-    jrnlOutput, err := c.Cmd.Output()
+// periods are the pages the dates being synced belong on. A week or month page
+// runs from the start of the week or month up to the last date being synced in
+// it, so a night that was missed is caught up on the next run.
+func (c *Config) periods() ([]notionPeriod, error) {
+    dates, err := datesToSync(c.DateForEntries, c.Today, c.Days)
     if err != nil {
-        return nil, fmt.Errorf("%w%s", ErrJrnlCommandFailed, err)
+        return nil, err
+    }
+    return c.periodsFor(dates)
+}
+
+// periodsFor gives the pages for dates in order, dates on the same week or
+// month page share one period.
+func (c *Config) periodsFor(dates []string) ([]notionPeriod, error) {
+    periods := make([]notionPeriod, 0, len(dates))
+    for _, date := range dates {
+        period, err := c.period(date)
+        if err != nil {
+            return nil, err
+        }
+        if len(periods) > 0 && periods[len(periods)-1].Title == period.Title {
+            periods[len(periods)-1] = period
+            continue
+        }
+        periods = append(periods, period)
+    }
+    return periods, nil
+}
+
+// loadTemplate reads the page template, if there is one, so sync and the
+// commands checking what it wrote lay pages out the same way.
+func (c *Config) loadTemplate() error {
+    if c.Template == "" {
+        return nil
     }
-    jrnlResp := JrnlBody{}
-    err = json.Unmarshal(jrnlOutput, &jrnlResp)
+    var err error
+    c.pageTemplate, err = loadNotionTemplate(c.Template)
+    return err
+}
+
+func (c *Config) period(dateForEntries string) (notionPeriod, error) {
+    switch c.GroupBy {
+    case "", GroupByDay, GroupByEntry:
+        return notionPeriod{Title: dateForEntries, From: dateForEntries, Date: dateForEntries}, nil
+    case GroupByWeek, GroupByMonth:
+    default:
+        return notionPeriod{}, fmt.Errorf("%w%q", ErrUnknownGroupBy, c.GroupBy)
+    }
+    date, err := time.Parse("2006-01-02", dateForEntries)
     if err != nil {
-        return nil, fmt.Errorf("%w%s", ErrFailedToUnmarshalJrnlOutput, err)
+        return notionPeriod{}, fmt.Errorf("%w%s", ErrInvalidDateForEntries, err)
     }
-    groupByDate := make(map[string][]string)
-    for _, e := range jrnlResp.Entries {
-        groupByDate[e.Date] = append(groupByDate[e.Date], e.Body)
+    if c.GroupBy == GroupByMonth {
+        first := date.AddDate(0, 0, 1-date.Day())
+        return notionPeriod{Title: date.Format("2006-01"), From: first.Format("2006-01-02"), Date: dateForEntries, To: first.AddDate(0, 1, -1).Format("2006-01-02")}, nil
     }
+    year, week := date.ISOWeek()
+    monday := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+    return notionPeriod{Title: fmt.Sprintf("%d-W%02d", year, week), From: monday.Format("2006-01-02"), Date: dateForEntries, To: monday.AddDate(0, 0, 6).Format("2006-01-02")}, nil
+}
 
-    return groupByDate, nil
+func (c *Config) pageGroups(period notionPeriod, entriesGroupedByDate map[string][]Entry) []notionPageGroup {
+    switch c.GroupBy {
+    case GroupByEntry:
+        entries := append([]Entry{}, entriesGroupedByDate[period.Date]...)
+        sort.SliceStable(entries, func(i, j int) bool {
+            return entries[i].Time < entries[j].Time
+        })
+        groups := make([]notionPageGroup, 0, len(entries))
+        for _, e := range entries {
+            groups = append(groups, notionPageGroup{Title: entryPageTitle(e), Date: e.Date, Entries: []Entry{e}})
+        }
+        return groups
+    case GroupByWeek, GroupByMonth:
+        entries := make([]Entry, 0)
+        for date, entriesForDate := range entriesGroupedByDate {
+            if date >= period.From && date <= period.Date {
+                entries = append(entries, entriesForDate...)
+            }
+        }
+        sort.SliceStable(entries, func(i, j int) bool {
+            if entries[i].Date != entries[j].Date {
+                return entries[i].Date < entries[j].Date
+            }
+            return entries[i].Time < entries[j].Time
+        })
+        return []notionPageGroup{{Title: period.Title, Date: period.From, To: period.To, Entries: entries}}
+    }
+    return []notionPageGroup{{Title: period.Title, Date: period.Date, Entries: entriesGroupedByDate[period.Date]}}
 }
 
-func (c *Config) postToNotion(notionDocument NotionDocument) error {
-    jsonBytes, err := json.Marshal(notionDocument)
-    if err != nil {
-        return err
+func entryPageTitle(e Entry) string {
+    title := e.Title
+    if title == "" {
+        title = strings.SplitN(strings.TrimSpace(e.Body), "\n", 2)[0]
     }
-    buf := bytes.NewBuffer(jsonBytes)
+    return strings.TrimSpace(fmt.Sprintf("%s %s %s", e.Date, e.Time, title))
+}
 
-    url := fmt.Sprintf("%s/pages", notionBaseAddress)
-    req, err := http.NewRequest("POST", url, buf)
+func (c *Config) planSync(api notionAPI, notionDocument NotionDocument) (notionPlan, error) {
+    plan := notionPlan{Action: "create", Document: notionDocument}
+    pages, err := api.findPagesByTitle(c.DBID, notionDocument.Title())
     if err != nil {
-        return err
+        return plan, err
+    }
+    for _, page := range pages {
+        if page.Archived {
+            continue
+        }
+        existing, err := api.blockChildren(page.ID)
+        if err != nil {
+            return plan, err
+        }
+        plan.PageID = page.ID
+        plan.Existing = existing
+        // pages synced before jrnlSync kept the journal date get it now
+        plan.SetDate = !sameNotionDate(page.Properties.Date, notionDocument.Properties.Date)
+        break
+    }
+    if plan.PageID == "" {
+        return plan, nil
     }
 
-    req.Header = http.Header{
-        "Content-Type": []string{"application/json"},
-        "Authorization": []string{fmt.Sprintf("Bearer %s", c.NotionKey)},
-        "Notion-Version": []string{"2021-08-16"},
+    if c.botUserID == "" {
+        c.botUserID, err = api.botUserID()
+        if err != nil {
+            return plan, err
+        }
     }
 
-    res, err := c.HttpClient.Do(req)
-    if err != nil {
-        return fmt.Errorf("%w%s", ErrPostingToNotion, err)
+    grouped := c.GroupBy == GroupByWeek || c.GroupBy == GroupByMonth
+    existing := splitSections(plan.Existing, grouped)
+    rendered := splitSections(notionDocument.Children, grouped)
+    renderedByDate := make(map[string]notionSection)
+    for _, section := range rendered {
+        renderedByDate[section.Date] = section
     }
+    // the old version of a date's entries goes, anything written straight into
+    // notion (like notes from your phone waiting to be pulled) stays
+    deleted := make(map[string]bool)
+    onPage := make(map[string]notionSection)
+    for _, current := range existing {
+        onPage[current.Date] = current
+        section, ok := renderedByDate[current.Date]
+        if !ok || (grouped && current.Date == "") {
+            continue
+        }
+        for _, b := range c.staleBlocks(current.Blocks, section.Blocks) {
+            deleted[b.ID] = true
+            plan.Delete = append(plan.Delete, b.ID)
+        }
+    }
+
+    for _, section := range rendered {
+        if current, ok := onPage[section.Date]; ok {
+            plan.insert(current.lastBlockID(deleted), missingBlocks(current.kept(deleted), section.Blocks))
+            continue
+        }
+        // a date that isn't on the page yet goes after the date before it,
+        // notion can only add blocks after another one so a date before
+        // everything on the page ends up at the bottom
+        after := existing[0].lastBlockID(deleted)
+        for _, current := range existing[1:] {
+            if current.Date < section.Date {
+                after = current.lastBlockID(deleted)
+            }
+        }
+        plan.insert(after, append([]Block{*section.Heading}, section.Blocks...))
+    }
+    plan.Action = "unchanged"
+    if len(plan.Inserts) > 0 || len(plan.Delete) > 0 || plan.SetDate {
+        plan.Action = "update"
+    }
+    return plan, nil
+}
 
-    if res.StatusCode > 299 {
-        return fmt.Errorf("%w%s", ErrHTTPStatus, res.Status)
+// staleBlocks are the blocks jrnlSync wrote for a date that no longer match its
+// entries. When jrnlSync wrote all of them they all go so the new version keeps
+// the entries in order, otherwise only the ones that changed.
+func (c *Config) staleBlocks(have, want []Block) []Block {
+    if len(missingBlocks(have, want)) == 0 && len(missingBlocks(want, have)) == 0 {
+        return nil
+    }
+    ours := make([]Block, 0, len(have))
+    for _, b := range have {
+        if b.CreatedBy != nil && b.CreatedBy.ID == c.botUserID {
+            ours = append(ours, b)
+        }
+    }
+    if len(ours) == len(have) {
+        return ours
+    }
+    return missingBlocks(want, ours)
+}
+
+// insert keeps blocks going after the same block in one insert, in the order
+// they're planned
+func (p *notionPlan) insert(after string, blocks []Block) {
+    if len(blocks) == 0 {
+        return
+    }
+    for i := range p.Inserts {
+        if p.Inserts[i].After == after {
+            p.Inserts[i].Blocks = append(p.Inserts[i].Blocks, blocks...)
+            return
+        }
+    }
+    p.Inserts = append(p.Inserts, notionInsert{After: after, Blocks: blocks})
+}
+
+func splitSections(blocks []Block, grouped bool) []notionSection {
+    sections := []notionSection{{}}
+    for i, b := range blocks {
+        if grouped && b.Type == "heading_2" && isEntryDate(b.PlainText()) {
+            sections = append(sections, notionSection{Date: b.PlainText(), Heading: &blocks[i]})
+            continue
+        }
+        sections[len(sections)-1].Blocks = append(sections[len(sections)-1].Blocks, b)
+    }
+    return sections
+}
+
+func (s notionSection) kept(deleted map[string]bool) []Block {
+    kept := make([]Block, 0, len(s.Blocks))
+    for _, b := range s.Blocks {
+        if !deleted[b.ID] {
+            kept = append(kept, b)
+        }
+    }
+    return kept
+}
+
+func (s notionSection) lastBlockID(deleted map[string]bool) string {
+    if kept := s.kept(deleted); len(kept) > 0 {
+        return kept[len(kept)-1].ID
+    }
+    if s.Heading != nil {
+        return s.Heading.ID
+    }
+    return ""
+}
+
+// missingBlocks are the blocks in want that aren't in have, counting repeats so
+// a second "Gym." on the same day isn't taken for the first
+func missingBlocks(have, want []Block) []Block {
+    counts := make(map[string]int)
+    for _, b := range have {
+        counts[normalizeEntryText(b.PlainText())]++
+    }
+    missing := make([]Block, 0)
+    for _, b := range want {
+        text := normalizeEntryText(b.PlainText())
+        if counts[text] > 0 {
+            counts[text]--
+            continue
+        }
+        missing = append(missing, b)
+    }
+    return missing
+}
+
+func sameNotionDate(a, b *NotionDateProperty) bool {
+    if a == nil || a.Date == nil || b == nil || b.Date == nil {
+        return (a == nil || a.Date == nil) == (b == nil || b.Date == nil)
+    }
+    return dateOnly(a.Date.Start) == b.Date.Start && dateOnly(a.Date.End) == b.Date.End
+}
+
+func (c *Config) applyPlan(api notionAPI, plan notionPlan) error {
+    if plan.Action == "create" || plan.SetDate {
+        err := c.ensureDateProperty(api)
+        if err != nil {
+            return err
+        }
+    }
+    switch plan.Action {
+    case "create":
+        return api.createPage(plan.Document)
+    case "update":
+        if plan.SetDate {
+            err := api.updatePageDate(plan.PageID, plan.Document.Properties.Date)
+            if err != nil {
+                return err
+            }
+        }
+        // the new version goes in before the old one comes out, a sync that
+        // fails part way leaves both for the next sync to tidy up
+        for _, insert := range plan.Inserts {
+            err := api.insertBlocks(plan.PageID, insert.After, insert.Blocks)
+            if err != nil {
+                return err
+            }
+        }
+        for _, id := range plan.Delete {
+            err := api.deleteBlock(id)
+            if err != nil {
+                return err
+            }
+        }
     }
     return nil
+}
+
+func (c *Config) printPlan(plan notionPlan) error {
+    out := c.Out
+    if out == nil {
+        out = io.Discard
+    }
+    switch plan.Action {
+    case "create":
+        fmt.Fprintf(out, "%s: create a new page with %d blocks\n", plan.Document.Title(), len(plan.Document.Children))
+        payload, err := json.MarshalIndent(plan.Document, "", "  ")
+        if err != nil {
+            return err
+        }
+        fmt.Fprintf(out, "%s\n", payload)
+    case "update":
+        inserted := make(map[string][]Block)
+        count := 0
+        for _, insert := range plan.Inserts {
+            inserted[insert.After] = insert.Blocks
+            count += len(insert.Blocks)
+        }
+        deleted := make(map[string]bool)
+        for _, id := range plan.Delete {
+            deleted[id] = true
+        }
+        fmt.Fprintf(out, "%s: add %d blocks to and remove %d blocks from page %s\n", plan.Document.Title(), count, len(plan.Delete), plan.PageID)
+        if plan.SetDate {
+            fmt.Fprintf(out, "set its %s to %s\n", notionDateProperty, formatNotionDate(plan.Document.Properties.Date))
+        }
+        printInserted := func(blocks []Block) {
+            for _, b := range blocks {
+                fmt.Fprintf(out, "+ %s\n", b.PlainText())
+            }
+        }
+        for _, b := range plan.Existing {
+            if deleted[b.ID] {
+                fmt.Fprintf(out, "- %s\n", b.PlainText())
+            } else {
+                fmt.Fprintf(out, "  %s\n", b.PlainText())
+            }
+            printInserted(inserted[b.ID])
+        }
+        printInserted(inserted[""])
+        for _, insert := range plan.Inserts {
+            payload, err := json.MarshalIndent(notionBlockChildren{Children: insert.Blocks, After: insert.After}, "", "  ")
+            if err != nil {
+                return err
+            }
+            fmt.Fprintf(out, "%s\n", payload)
+        }
+    default:
+        fmt.Fprintf(out, "%s: page %s is up to date, nothing to send\n", plan.Document.Title(), plan.PageID)
+    }
+    return nil
+}
 
+// ensureDateProperty adds the journal date property to databases made before
+// jrnlSync kept one, once per sync
+func (c *Config) ensureDateProperty(api notionAPI) error {
+    if c.hasDateProperty {
+        return nil
+    }
+    database, err := api.database(c.DBID)
+    if err != nil {
+        return err
+    }
+    property, ok := database.Properties[notionDateProperty]
+    switch {
+    case !ok:
+        err = api.addDateProperty(c.DBID, notionDateProperty)
+        if err != nil {
+            return err
+        }
+    case property.Type != "date":
+        return fmt.Errorf("%w%q", ErrDatePropertyConflict, notionDateProperty)
+    }
+    c.hasDateProperty = true
+    return nil
+}
+
+func formatNotionDate(property *NotionDateProperty) string {
+    if property == nil || property.Date == nil {
+        return "nothing"
+    }
+    if property.Date.End == "" {
+        return property.Date.Start
+    }
+    return fmt.Sprintf("%s to %s", property.Date.Start, property.Date.End)
 }
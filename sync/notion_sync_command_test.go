@@ -8,8 +8,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/jm96441n/jrnlSync/profile"
 	"github.com/jm96441n/jrnlSync/sync"
 )
 
@@ -188,6 +194,802 @@ func TestReturnsErrWhenFailingToNotionRespondsWithAFailureForStatusCode(t *testi
     }
 }
 
+func TestExecAppendsOnlyNewEntriesToAnExistingPage(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "the last one", "Written on my phone")
+
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(notion.pages) != 1 {
+        t.Fatalf("expected the existing page to be reused, got %d pages", len(notion.pages))
+    }
+    texts := []string{}
+    for _, b := range notion.pages[0].blocks {
+        texts = append(texts, b.PlainText())
+    }
+    expected := []string{"the last one", "Written on my phone", "next one", "The new one"}
+    if strings.Join(texts, "|") != strings.Join(expected, "|") {
+        t.Errorf("expected page blocks to be %v, got %v", expected, texts)
+    }
+}
+
+func TestExecFillsInEachDayOfAWeekPageUnderItsOwnHeading(t *testing.T) {
+    output := `{"tags": {}, "entries": [
+        {"title": "Gym.", "body": "Gym.", "date": "2021-11-22", "time": "07:00"},
+        {"title": "Gym.", "body": "Gym.", "date": "2021-11-23", "time": "07:00"},
+        {"title": "Gym.", "body": "Gym.", "date": "2021-11-24", "time": "07:00"},
+        {"title": "Standup.", "body": "Talked.", "date": "2021-11-24", "time": "09:00"},
+        {"title": "Gym.", "body": "Gym.", "date": "2021-11-24", "time": "18:00"}
+    ]}`
+    notion := newFakeNotion()
+    page := notion.addPage("2021-W47", "2021-11-22", "[07:00] Gym.\nGym.", "2021-11-24", "[07:00] Gym.\nGym.", "[09:00] Standup.\nTalked.")
+    for _, i := range []int{0, 2} {
+        page.blocks[i].Type = "heading_2"
+        page.blocks[i].Heading2, page.blocks[i].BulletedList = page.blocks[i].BulletedList, nil
+    }
+
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        DateForEntries: "2021-11-24",
+        GroupBy: sync.GroupByWeek,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    texts := []string{}
+    for _, b := range notion.pages[0].blocks {
+        texts = append(texts, b.PlainText())
+    }
+    expected := []string{
+        "2021-11-22", "[07:00] Gym.\nGym.", "2021-11-23", "[07:00] Gym.\nGym.",
+        "2021-11-24", "[07:00] Gym.\nGym.", "[09:00] Standup.\nTalked.", "[18:00] Gym.\nGym.",
+    }
+    if !reflect.DeepEqual(expected, texts) {
+        t.Errorf("expected page blocks to be %q, got %q", expected, texts)
+    }
+}
+
+func TestExecKeepsTheJournalDateOfEachPage(t *testing.T) {
+    notion := newFakeNotion()
+    // synced before jrnlSync kept the journal date
+    notion.addPage("2021-W47", "2021-11-22", "Ran 5k")
+
+    for _, date := range []string{"2021-11-24", "2021-11-30"} {
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: weekJrnlOutput},
+            DateForEntries: date,
+            GroupBy: sync.GroupByWeek,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    if notion.properties["Journal date"]["type"] != "date" {
+        t.Errorf("expected a journal date property to be added to the database, got %v", notion.properties)
+    }
+    dates := make(map[string]sync.NotionDate)
+    for _, page := range notion.pages {
+        if page.page.Properties.Date != nil && page.page.Properties.Date.Date != nil {
+            dates[page.page.Title()] = *page.page.Properties.Date.Date
+        }
+    }
+    expected := map[string]sync.NotionDate{
+        "2021-W47": {Start: "2021-11-22", End: "2021-11-28"},
+        "2021-W48": {Start: "2021-11-29", End: "2021-12-05"},
+    }
+    if !reflect.DeepEqual(expected, dates) {
+        t.Errorf("expected page dates %v, got %v", expected, dates)
+    }
+}
+
+func TestExecReplacesAnEditedEntryOnTheNextSync(t *testing.T) {
+    edited, err := buildOutputString(
+        map[string]string{"body": "Too early", "date": "2021-11-23"},
+        []map[string]string{
+            {"body": "The new one", "date": "2021-11-24"},
+            {"body": "next one, with a typo fixed", "date": "2021-11-24"},
+            {"body": "the last one", "date": "2021-11-24"},
+        },
+        map[string]string{"body": "Too late", "date": "2021-11-25"},
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    testCases := []struct{
+        name string
+        writtenInNotion string
+        expected []string
+    }{
+        {
+            name: "when jrnlSync wrote the whole page",
+            expected: []string{"the last one", "next one, with a typo fixed", "The new one"},
+        },
+        {
+            name: "when something was written in notion too",
+            writtenInNotion: "Written on my phone",
+            expected: []string{"the last one", "The new one", "Written on my phone", "next one, with a typo fixed"},
+        },
+    }
+
+    for _, testCase := range testCases {
+        notion := newFakeNotion()
+        runSync := func(output string) {
+            config := sync.Config{
+                DBID: "mockdbid",
+                NotionKey: "fakeNotionKey",
+                HttpClient: notion,
+                Cmd: mockCommand{outputString: output},
+                DateForEntries: "2021-11-24",
+            }
+            err := config.Exec(context.Background(), []string{})
+            if err != nil {
+                t.Fatal(err)
+            }
+        }
+        runSync(jrnlOutputFixture(t))
+        if testCase.writtenInNotion != "" {
+            phone := newFakeNotion().addPage("", testCase.writtenInNotion).blocks[0]
+            phone.ID = "written-in-notion"
+            notion.pages[0].blocks = append(notion.pages[0].blocks, phone)
+        }
+        runSync(edited)
+        runSync(edited)
+
+        if len(notion.pages) != 1 {
+            t.Fatalf("%s: expected the page to be reused, got %d pages", testCase.name, len(notion.pages))
+        }
+        texts := []string{}
+        for _, b := range notion.pages[0].blocks {
+            texts = append(texts, b.PlainText())
+        }
+        if !reflect.DeepEqual(testCase.expected, texts) {
+            t.Errorf("%s: expected page blocks to be %q, got %q", testCase.name, testCase.expected, texts)
+        }
+    }
+}
+
+func TestExecWithDryRunPrintsThePlanWithoutWriting(t *testing.T) {
+    testCases := []struct{
+        name string
+        existingBlocks []string
+        writtenByJrnlSync bool
+        expectedOutput []string
+    }{
+        {
+            name: "when there is no page for the date",
+            expectedOutput: []string{"2021-11-24: create a new page with 3 blocks", `"database_id": "mockdbid"`, `"content": "The new one"`},
+        },
+        {
+            name: "when the page is missing entries",
+            existingBlocks: []string{"the last one"},
+            expectedOutput: []string{"2021-11-24: add 2 blocks to and remove 0 blocks from page page-1", "  the last one\n+ next one\n+ The new one", `"after": "page-1-block-1"`},
+        },
+        {
+            name: "when an entry was edited",
+            existingBlocks: []string{"the last one", "next one, before the edit", "The new one"},
+            writtenByJrnlSync: true,
+            expectedOutput: []string{
+                "2021-11-24: add 3 blocks to and remove 3 blocks from page page-1",
+                "- the last one\n- next one, before the edit\n- The new one\n+ the last one\n+ next one\n+ The new one",
+            },
+        },
+        {
+            name: "when the page is up to date",
+            existingBlocks: []string{"the last one", "next one", "The new one"},
+            expectedOutput: []string{"2021-11-24: page page-1 is up to date, nothing to send"},
+        },
+    }
+
+    for _, testCase := range testCases {
+        notion := newFakeNotion()
+        if testCase.existingBlocks != nil {
+            page := notion.addPage("2021-11-24", testCase.existingBlocks...)
+            for i := range page.blocks {
+                if testCase.writtenByJrnlSync {
+                    page.blocks[i].CreatedBy = &sync.NotionUser{ID: fakeNotionBotID}
+                }
+            }
+        }
+        out := &bytes.Buffer{}
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+            DateForEntries: "2021-11-24",
+            DryRun: true,
+            Out: out,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, expected := range testCase.expectedOutput {
+            if !strings.Contains(out.String(), expected) {
+                t.Errorf("%s: expected output to contain %q, got %q", testCase.name, expected, out.String())
+            }
+        }
+        for _, request := range notion.requests {
+            if strings.HasPrefix(request, "PATCH") || strings.HasPrefix(request, "DELETE") || request == "POST /pages" {
+                t.Errorf("%s: expected no writes during a dry run, got %s", testCase.name, request)
+            }
+        }
+    }
+}
+
+func TestExecWithDryRunDoesNotChangeWhatTheSyncWrites(t *testing.T) {
+    // what's read (like the bot user, which is only looked up once) can differ
+    writes := func(dryRunFirst bool) ([]string, []string) {
+        notion := newFakeNotion()
+        notion.addPage("2021-11-24", "the last one")
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+            DateForEntries: "2021-11-24",
+            Out: &bytes.Buffer{},
+        }
+        if dryRunFirst {
+            config.DryRun = true
+            err := config.Exec(context.Background(), []string{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            config.DryRun = false
+            notion.requests = nil
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        requests := []string{}
+        for _, request := range notion.requests {
+            if !strings.HasPrefix(request, "GET") && request != "POST /databases/mockdbid/query" {
+                requests = append(requests, request)
+            }
+        }
+        texts := []string{}
+        for _, b := range notion.pages[0].blocks {
+            texts = append(texts, b.PlainText())
+        }
+        return requests, texts
+    }
+
+    requests, texts := writes(false)
+    requestsAfterDryRun, textsAfterDryRun := writes(true)
+    if !reflect.DeepEqual(requests, requestsAfterDryRun) {
+        t.Errorf("expected a dry run not to change the writes sync makes, got %v and %v", requests, requestsAfterDryRun)
+    }
+    if !reflect.DeepEqual(texts, textsAfterDryRun) {
+        t.Errorf("expected a dry run not to change what sync writes, got %q and %q", texts, textsAfterDryRun)
+    }
+}
+
+func TestExecAsksJrnlForOnlyTheDateProvided(t *testing.T) {
+    cmd := &mockRangedCommand{mockCommand: mockCommand{outputString: jrnlOutputFixture(t)}}
+    notion := newFakeNotion()
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: cmd,
+        DateForEntries: "2021-11-24",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if cmd.from != "2021-11-24" || cmd.to != "2021-11-24" {
+        t.Errorf("expected jrnl to be asked for 2021-11-24 to 2021-11-24, got %s to %s", cmd.from, cmd.to)
+    }
+}
+
+func TestExecGroupsEntriesIntoPages(t *testing.T) {
+    output := `{"tags": {}, "entries": [
+        {"title": "Start of month.", "body": "Month start", "date": "2021-11-01", "time": "09:00"},
+        {"title": "Last week.", "body": "Still last week", "date": "2021-11-21", "time": "09:00"},
+        {"title": "Monday run.", "body": "Ran 5k", "date": "2021-11-22", "time": "08:00"},
+        {"title": "Standup.", "body": "Talked.", "date": "2021-11-24", "time": "09:00"},
+        {"title": "Dinner.", "body": "Made soup.\n\nIt was good.", "date": "2021-11-24", "time": "18:30"}
+    ]}`
+    testCases := []struct{
+        groupBy string
+        expectedPages map[string][]string
+    }{
+        {
+            groupBy: sync.GroupByEntry,
+            expectedPages: map[string][]string{
+                "2021-11-24 09:00 Standup.": {"[09:00] Standup.\nTalked."},
+                "2021-11-24 18:30 Dinner.": {"[18:30] Dinner.\nMade soup.", "It was good."},
+            },
+        },
+        {
+            groupBy: sync.GroupByWeek,
+            expectedPages: map[string][]string{
+                "2021-W47": {
+                    "2021-11-22", "[08:00] Monday run.\nRan 5k",
+                    "2021-11-24", "[09:00] Standup.\nTalked.", "[18:30] Dinner.\nMade soup.\n\nIt was good.",
+                },
+            },
+        },
+        {
+            groupBy: sync.GroupByMonth,
+            expectedPages: map[string][]string{
+                "2021-11": {
+                    "2021-11-01", "[09:00] Start of month.\nMonth start", "2021-11-21", "[09:00] Last week.\nStill last week",
+                    "2021-11-22", "[08:00] Monday run.\nRan 5k",
+                    "2021-11-24", "[09:00] Standup.\nTalked.", "[18:30] Dinner.\nMade soup.\n\nIt was good.",
+                },
+            },
+        },
+    }
+
+    for _, testCase := range testCases {
+        notion := newFakeNotion()
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: output},
+            DateForEntries: "2021-11-24",
+            GroupBy: testCase.groupBy,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pages := make(map[string][]string)
+        for _, page := range notion.pages {
+            texts := []string{}
+            for _, b := range page.blocks {
+                texts = append(texts, b.PlainText())
+            }
+            pages[page.page.Title()] = texts
+        }
+        if !reflect.DeepEqual(testCase.expectedPages, pages) {
+            t.Errorf("grouping by %s: expected pages %q, got %q", testCase.groupBy, testCase.expectedPages, pages)
+        }
+    }
+}
+
+func TestExecWithDaysSyncsEachDateWithEntriesUpToToday(t *testing.T) {
+    output := `{"tags": {}, "entries": [
+        {"title": "Too early.", "body": "Before the days", "date": "2021-11-21", "time": "09:00"},
+        {"title": "Monday run.", "body": "Ran 5k", "date": "2021-11-22", "time": "08:00"},
+        {"title": "Standup.", "body": "Talked.", "date": "2021-11-24", "time": "09:00"},
+        {"title": "Coffee.", "body": "Early one.", "date": "2021-11-25", "time": "07:00"}
+    ]}`
+    testCases := []struct{
+        groupBy string
+        expectedPages map[string][]string
+    }{
+        {
+            groupBy: sync.GroupByDay,
+            expectedPages: map[string][]string{
+                "2021-11-22": {"[08:00] Monday run.\nRan 5k"},
+                "2021-11-24": {"[09:00] Standup.\nTalked."},
+                "2021-11-25": {"[07:00] Coffee.\nEarly one."},
+            },
+        },
+        {
+            groupBy: sync.GroupByWeek,
+            expectedPages: map[string][]string{
+                "2021-W47": {
+                    "2021-11-22", "[08:00] Monday run.\nRan 5k", "2021-11-24", "[09:00] Standup.\nTalked.",
+                    "2021-11-25", "[07:00] Coffee.\nEarly one.",
+                },
+            },
+        },
+    }
+
+    for _, testCase := range testCases {
+        notion := newFakeNotion()
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: output},
+            DateForEntries: "2021-11-24",
+            Days: 4,
+            Today: "2021-11-25",
+            GroupBy: testCase.groupBy,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pages := make(map[string][]string)
+        for _, page := range notion.pages {
+            texts := []string{}
+            for _, b := range page.blocks {
+                texts = append(texts, b.PlainText())
+            }
+            pages[page.page.Title()] = texts
+        }
+        if !reflect.DeepEqual(testCase.expectedPages, pages) {
+            t.Errorf("grouping by %s: expected pages %q, got %q", testCase.groupBy, testCase.expectedPages, pages)
+        }
+    }
+}
+
+func TestReturnsErrForInvalidDaysToSync(t *testing.T) {
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: newFakeNotion(),
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        Days: -1,
+        Today: "2021-11-25",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrInvalidDaysToSync) {
+        t.Errorf("Expected error to be %q, got %q", sync.ErrInvalidDaysToSync, err)
+    }
+}
+
+func TestReturnsErrForAnUnknownGroupBy(t *testing.T) {
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: newFakeNotion(),
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        GroupBy: "year",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrUnknownGroupBy) {
+        t.Errorf("Expected error to be of type ErrUnknownGroupBy, got %+v", err)
+    }
+}
+
+func TestExecLaysOutPagesWithTheTemplate(t *testing.T) {
+    templateFile := filepath.Join(t.TempDir(), "page.tmpl")
+    err := os.WriteFile(templateFile, []byte(`
+{{define "title"}}{{.Date.Format "Mon 02 Jan 2006"}} — {{len .Entries}} entries{{end}}
+{{define "entry"}}
+{{- heading3 (printf "%s %s" .Time .Title) -}}
+{{- if .Starred}}{{callout "⭐" .Body}}{{else}}{{toggle "Read more" .Body}}{{end -}}
+{{- with .Tags}}Tags: {{join . ", "}}{{end -}}
+{{end}}`), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    output := `{"tags": {}, "entries": [
+        {"title": "Standup.", "body": "Talked.\n\nAbout things.", "date": "2021-11-24", "time": "09:00", "tags": ["@work"]},
+        {"title": "Dinner.", "body": "Made soup.", "date": "2021-11-24", "time": "18:30", "tags": [], "starred": true}
+    ]}`
+
+    notion := newFakeNotion()
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        DateForEntries: "2021-11-24",
+        Template: templateFile,
+    }
+    err = config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(notion.pages) != 1 {
+        t.Fatalf("expected 1 page, got %d", len(notion.pages))
+    }
+    page := notion.pages[0]
+    if page.page.Title() != "Wed 24 Nov 2021 — 2 entries" {
+        t.Errorf("expected the title from the template, got %q", page.page.Title())
+    }
+    blocks := []string{}
+    for _, b := range page.blocks {
+        blocks = append(blocks, fmt.Sprintf("%s:%s", b.Type, b.PlainText()))
+    }
+    expected := []string{
+        "heading_3:09:00 Standup.",
+        "toggle:Read more",
+        "paragraph:Tags: @work",
+        "heading_3:18:30 Dinner.",
+        "callout:Made soup.",
+    }
+    if !reflect.DeepEqual(expected, blocks) {
+        t.Errorf("expected blocks %q, got %q", expected, blocks)
+    }
+    if len(page.blocks) == len(expected) {
+        toggled := []string{}
+        for _, b := range page.blocks[1].Toggle.Children {
+            toggled = append(toggled, b.PlainText())
+        }
+        if !reflect.DeepEqual([]string{"Talked.", "About things."}, toggled) {
+            t.Errorf("expected the body inside the toggle, got %q", toggled)
+        }
+        if icon := page.blocks[4].Callout.Icon; icon == nil || icon.Emoji != "⭐" {
+            t.Errorf("expected the callout to have a star icon, got %+v", icon)
+        }
+    }
+}
+
+func TestReturnsErrForAnInvalidTemplate(t *testing.T) {
+    templateFile := filepath.Join(t.TempDir(), "page.tmpl")
+    err := os.WriteFile(templateFile, []byte(`{{define "title"}}{{.Date.Format{{end}}`), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: newFakeNotion(),
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        Template: templateFile,
+    }
+    err = config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrInvalidTemplate) {
+        t.Errorf("Expected error to be of type ErrInvalidTemplate, got %+v", err)
+    }
+}
+
+const filterJrnlOutput = `{"tags": {}, "entries": [
+    {"title": "Standup.", "body": "Talked about the @work release.", "date": "2021-11-24", "time": "09:00", "tags": ["@work"]},
+    {"title": "Therapy.", "body": "Talked about #feelings, @private.", "date": "2021-11-24", "time": "12:00", "tags": ["#feelings", "@private"]},
+    {"title": "Dinner.", "body": "Made soup with #family.", "date": "2021-11-24", "time": "18:30", "tags": ["#family"]}
+]}`
+
+func TestExecFiltersEntriesByTag(t *testing.T) {
+    testCases := []struct{
+        name string
+        filter sync.EntryFilter
+        expectedBlocks []string
+    }{
+        {
+            name: "include tags",
+            filter: sync.EntryFilter{IncludeTags: []string{"work", "#family"}},
+            expectedBlocks: []string{"[09:00] Standup.\nTalked about the @work release.", "[18:30] Dinner.\nMade soup with #family."},
+        },
+        {
+            name: "exclude tags",
+            filter: sync.EntryFilter{ExcludeTags: []string{"@WORK"}},
+            expectedBlocks: []string{"[12:00] Therapy.\nTalked about #feelings, @private.", "[18:30] Dinner.\nMade soup with #family."},
+        },
+        {
+            name: "skipping private entries",
+            filter: sync.EntryFilter{PrivateTag: "private", PrivateAction: sync.PrivateActionSkip},
+            expectedBlocks: []string{"[09:00] Standup.\nTalked about the @work release.", "[18:30] Dinner.\nMade soup with #family."},
+        },
+        {
+            name: "replacing private entries",
+            filter: sync.EntryFilter{PrivateTag: "@private", PrivateAction: sync.PrivateActionPlaceholder, Placeholder: "Private entry"},
+            expectedBlocks: []string{
+                "[09:00] Standup.\nTalked about the @work release.", "[12:00] Private entry", "[18:30] Dinner.\nMade soup with #family.",
+            },
+        },
+    }
+
+    for _, testCase := range testCases {
+        notion := newFakeNotion()
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: filterJrnlOutput},
+            DateForEntries: "2021-11-24",
+            Filter: testCase.filter,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        blocks := []string{}
+        for _, b := range notion.pages[0].blocks {
+            blocks = append(blocks, b.PlainText())
+        }
+        if !reflect.DeepEqual(testCase.expectedBlocks, blocks) {
+            t.Errorf("%s: expected blocks %q, got %q", testCase.name, testCase.expectedBlocks, blocks)
+        }
+    }
+}
+
+func TestReturnsErrForAnUnknownPrivateAction(t *testing.T) {
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: newFakeNotion(),
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        Filter: sync.EntryFilter{PrivateTag: "@private", PrivateAction: "shred"},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrUnknownPrivateAction) {
+        t.Errorf("Expected error to be of type ErrUnknownPrivateAction, got %+v", err)
+    }
+}
+
+func TestExecRedactsEntriesBeforeSending(t *testing.T) {
+    rulesFile := filepath.Join(t.TempDir(), "redact.yaml")
+    err := os.WriteFile(rulesFile, []byte(`hash_key: not-so-secret
+rules:
+  - name: employee
+    pattern: 'EMP-\d{6}'
+    action: hash
+  - name: swearing
+    pattern: '(?i)\s*darn'
+    action: remove
+`), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    output := `{"tags": {}, "entries": [
+        {"title": "Call.", "body": "Rang jane@example.com back on (555) 123-4567 about EMP-123456.", "date": "2021-11-24", "time": "09:00"},
+        {"title": "Lunch.", "body": "Paid with 4111 1111 1111 1111, darn it. EMP-123456 came too.", "date": "2021-11-24", "time": "12:00"}
+    ]}`
+
+    notion := newFakeNotion()
+    out := &bytes.Buffer{}
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        DateForEntries: "2021-11-24",
+        Redact: sync.Redactor{
+            Builtins: []string{"phone", "email", "card"},
+            Action: sync.RedactActionMask,
+            RulesFile: rulesFile,
+            Out: out,
+        },
+    }
+    err = config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    blocks := []string{}
+    for _, b := range notion.pages[0].blocks {
+        blocks = append(blocks, b.PlainText())
+    }
+    expected := []string{
+        "[09:00] Call.\nRang [redacted email] back on [redacted phone] about [employee:da0046471451].",
+        "[12:00] Lunch.\nPaid with [redacted card], it. [employee:da0046471451] came too.",
+    }
+    if !reflect.DeepEqual(expected, blocks) {
+        t.Errorf("expected blocks %q, got %q", expected, blocks)
+    }
+    expectedReport := "2021-11-24: 6 redactions (card 1, email 1, employee 2, phone 1, swearing 1)\n"
+    if out.String() != expectedReport {
+        t.Errorf("expected the report %q, got %q", expectedReport, out.String())
+    }
+}
+
+func TestExecHashesWithTheKeyFromTheFlag(t *testing.T) {
+    output := `{"tags": {}, "entries": [
+        {"title": "Call.", "body": "Rang jane@example.com back.", "date": "2021-11-24", "time": "09:00"}
+    ]}`
+    notion := newFakeNotion()
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: output},
+        DateForEntries: "2021-11-24",
+        Redact: sync.Redactor{
+            Builtins: []string{"email"},
+            Action: sync.RedactActionHash,
+            HashKey: "not-so-secret",
+            Out: &bytes.Buffer{},
+        },
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    text := notion.pages[0].blocks[0].PlainText()
+    if !regexp.MustCompile(`^\[09:00\] Call\.\nRang \[email:[0-9a-f]{12}\] back\.$`).MatchString(text) {
+        t.Errorf("expected the email to be hashed, got %q", text)
+    }
+}
+
+func TestReturnsErrWhenHashingWithoutAKey(t *testing.T) {
+    notion := newFakeNotion()
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        Redact: sync.Redactor{Builtins: []string{"email"}, Action: sync.RedactActionHash},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrMissingRedactHashKey) {
+        t.Errorf("Expected error to be of type ErrMissingRedactHashKey, got %+v", err)
+    }
+    if len(notion.pages) != 0 {
+        t.Errorf("Expected nothing to be sent to notion, got %d pages", len(notion.pages))
+    }
+}
+
+func TestReturnsErrForAnUnknownRedactionRule(t *testing.T) {
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: newFakeNotion(),
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        Redact: sync.Redactor{Builtins: []string{"ssn"}},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrUnknownRedactionRule) {
+        t.Errorf("Expected error to be of type ErrUnknownRedactionRule, got %+v", err)
+    }
+}
+
+func TestExecReadsTheDatabaseAndKeyFromAProfile(t *testing.T) {
+    store := profile.NewStore(filepath.Join(t.TempDir(), "profiles.yaml"))
+    err := store.Save("work", profile.Profile{NotionDBID: "workdb", NotionKey: "secret_work"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    httpClient := &recordingHTTPClient{}
+    config := sync.Config{
+        HttpClient: httpClient,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        DateForEntries: "2021-11-24",
+        Profile: sync.NotionProfile{Name: "work", File: store.Path},
+    }
+    err = config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if config.DBID != "workdb" {
+        t.Errorf("expected the database id from the profile, got %q", config.DBID)
+    }
+    if len(httpClient.authorizations) == 0 {
+        t.Fatal("expected requests to be sent to notion")
+    }
+    for _, auth := range httpClient.authorizations {
+        if auth != "Bearer secret_work" {
+            t.Errorf("expected requests to use the key from the profile, got %q", auth)
+        }
+    }
+}
+
+func jrnlOutputFixture(t *testing.T) string {
+    outputString, err := buildOutputString(
+        map[string]string{"body": "Too early", "date": "2021-11-23"},
+        []map[string]string{
+            {"body": "The new one", "date": "2021-11-24"},
+            {"body": "next one", "date": "2021-11-24"},
+            {"body": "the last one", "date": "2021-11-24"},
+        },
+        map[string]string{"body": "Too late", "date": "2021-11-25"},
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    return outputString
+}
+
 func buildOutputString(tooEarly map[string]string, rightDay []map[string]string, tooLate map[string]string) (string, error) {
     tooEarlyJson, err := json.Marshal(tooEarly)
     if err != nil {
@@ -237,7 +1039,7 @@ func (m *mockHTTPClient) Do (req *http.Request) (*http.Response, error) {
     resp := &http.Response{
         Status: fmt.Sprintf("%d", m.statusCode),
         StatusCode: m.statusCode,
-        Body: io.NopCloser(bytes.NewBuffer([]byte{})),
+        Body: io.NopCloser(bytes.NewBuffer([]byte(`{"object": "list", "results": [], "has_more": false}`))),
     }
     return resp, nil
 }
@@ -247,11 +1049,39 @@ type mockCommand struct {
     outputString string
 }
 
-func (mc mockCommand) Output() ([]byte, error) {
+func (mc mockCommand) StdoutPipe() (io.ReadCloser, error) {
+    return io.NopCloser(strings.NewReader(mc.outputString)), nil
+}
+
+func (mc mockCommand) Start() error {
+    return nil
+}
+
+func (mc mockCommand) Wait() error {
     if mc.errOnOutput {
-        return nil, errors.New("error on output")
+        return errors.New("error on output")
     }
+    return nil
+}
+
+type mockRangedCommand struct {
+    mockCommand
+    from string
+    to string
+}
+
+func (mc *mockRangedCommand) SetDateRange(from, to string) {
+    mc.from, mc.to = from, to
+}
+
+type recordingHTTPClient struct {
+    authorizations []string
+}
 
-    output := []byte(mc.outputString)
-    return output, nil
+func (m *recordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+    m.authorizations = append(m.authorizations, req.Header.Get("Authorization"))
+    return &http.Response{
+        StatusCode: 200,
+        Body: io.NopCloser(bytes.NewBufferString(`{"object": "list", "results": [], "has_more": false}`)),
+    }, nil
 }
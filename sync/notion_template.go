@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type notionTemplate struct {
+    tmpl *template.Template
+    out *bytes.Buffer
+    blocks []Block
+}
+
+type notionTitleData struct {
+    Title string
+    Date time.Time
+    GroupBy string
+    Entries []Entry
+}
+
+var ErrInvalidTemplate = errors.New("failed to load the page template: ")
+var ErrFailedToRenderTemplate = errors.New("failed to render the page template: ")
+
+func loadNotionTemplate(path string) (*notionTemplate, error) {
+    contents, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrInvalidTemplate, err)
+    }
+    t := &notionTemplate{}
+    t.tmpl, err = template.New("page").Funcs(t.funcs()).Parse(string(contents))
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrInvalidTemplate, err)
+    }
+    if t.tmpl.Lookup("title") == nil && t.tmpl.Lookup("entry") == nil {
+        return nil, fmt.Errorf("%w%s doesn't define a \"title\" or \"entry\" template", ErrInvalidTemplate, path)
+    }
+    return t, nil
+}
+
+func (t *notionTemplate) title(group notionPageGroup, groupBy string) (string, error) {
+    if t.tmpl.Lookup("title") == nil {
+        return group.Title, nil
+    }
+    date, _ := time.Parse("2006-01-02", group.Date)
+    buf := &bytes.Buffer{}
+    err := t.tmpl.ExecuteTemplate(buf, "title", notionTitleData{
+        Title: group.Title,
+        Date: date,
+        GroupBy: groupBy,
+        Entries: group.Entries,
+    })
+    if err != nil {
+        return "", fmt.Errorf("%w%s", ErrFailedToRenderTemplate, err)
+    }
+    return strings.TrimSpace(buf.String()), nil
+}
+
+// entryBlocks runs the entry template, the block funcs it calls add blocks in
+// order and any text it writes outside of them becomes paragraphs.
+func (t *notionTemplate) entryBlocks(e Entry) ([]Block, error) {
+    if t.tmpl.Lookup("entry") == nil {
+        return []Block{newTextBlock("bulleted_list_item", e.Body)}, nil
+    }
+    t.blocks = make([]Block, 0)
+    t.out = &bytes.Buffer{}
+    err := t.tmpl.ExecuteTemplate(t.out, "entry", e)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToRenderTemplate, err)
+    }
+    t.flushText()
+    return t.blocks, nil
+}
+
+func (t *notionTemplate) funcs() template.FuncMap {
+    // text the template wrote before a block func was called is flushed first
+    // so it stays in front of that block
+    add := func(blocks ...Block) string {
+        t.flushText()
+        t.blocks = append(t.blocks, blocks...)
+        return ""
+    }
+    textBlock := func(blockType string) func(string) string {
+        return func(text string) string {
+            return add(newTextBlock(blockType, text))
+        }
+    }
+    return template.FuncMap{
+        "heading1": textBlock("heading_1"),
+        "heading2": textBlock("heading_2"),
+        "heading3": textBlock("heading_3"),
+        "paragraph": textBlock("paragraph"),
+        "bullet": textBlock("bulleted_list_item"),
+        "numbered": textBlock("numbered_list_item"),
+        "quote": textBlock("quote"),
+        "todo": textBlock("to_do"),
+        "paragraphs": func(text string) string {
+            return add(newParagraphBlocks(text)...)
+        },
+        "toggle": func(summary, body string) string {
+            b := newTextBlock("toggle", summary)
+            b.Toggle.Children = newParagraphBlocks(body)
+            return add(b)
+        },
+        "callout": func(emoji, text string) string {
+            b := newTextBlock("callout", text)
+            b.Callout.Icon = &NotionIcon{Type: "emoji", Emoji: emoji}
+            return add(b)
+        },
+        "formatDate": func(layout, date string) (string, error) {
+            parsed, err := time.Parse("2006-01-02", date)
+            if err != nil {
+                return "", err
+            }
+            return parsed.Format(layout), nil
+        },
+        "join": strings.Join,
+    }
+}
+
+func (t *notionTemplate) flushText() {
+    if t.out == nil {
+        return
+    }
+    t.blocks = append(t.blocks, newParagraphBlocks(t.out.String())...)
+    t.out.Reset()
+}
@@ -0,0 +1,226 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type VerifyConfig struct {
+    DBID string
+    NotionKey string
+    Profile NotionProfile
+    HttpClient httpInteractor
+    Cmd commandOutputter
+    Filter EntryFilter
+    Redact Redactor
+    From string
+    To string
+    GroupBy string
+    Template string
+    Out io.Writer
+}
+
+var ErrBackupDrift = errors.New("the notion backup doesn't match jrnl: ")
+
+func NewNotionVerifyFlagSet(httpClient httpInteractor, cmd commandOutputter, dateForEntries string) *ffcli.Command {
+    c := &VerifyConfig{HttpClient: httpClient, Cmd: cmd, Out: os.Stdout}
+    verifyFlagSet := flag.NewFlagSet("jrnlsync verify", flag.ExitOnError)
+    verifyFlagSet.StringVar(&c.DBID, "d", "", "The id of the notion database the journal is backed up to")
+    verifyFlagSet.StringVar(&c.NotionKey, "k", "", "Your notion integration key")
+    c.Profile.RegisterFlags(verifyFlagSet)
+    verifyFlagSet.StringVar(&c.From, "from", "", "Only check dates on or after this date (YYYY-MM-DD)")
+    verifyFlagSet.StringVar(&c.To, "to", dateForEntries, "Only check dates on or before this date (YYYY-MM-DD)")
+    registerLayoutFlags(verifyFlagSet, &c.GroupBy, &c.Template)
+    c.Filter.RegisterFlags(verifyFlagSet)
+    c.Redact.RegisterFlags(verifyFlagSet)
+    registerSourceFlags(verifyFlagSet, cmd)
+
+    return &ffcli.Command{
+        Name:       "verify",
+        ShortUsage: "jrnlSync verify -d [DATABASE_ID] -k [NOTION_INTEGRATION_KEY] [-from DATE] [-to DATE]",
+        ShortHelp:  "Checks that every day in jrnl has been backed up to your notion database",
+        FlagSet:    verifyFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *VerifyConfig) Exec(_ context.Context, _ []string) error {
+    err := c.Profile.fill(&c.DBID, &c.NotionKey)
+    if err != nil {
+        return err
+    }
+    // the pages are rendered just like sync does so a template or group-by
+    // gives the titles and blocks sync would have written
+    layout := &Config{DBID: c.DBID, GroupBy: c.GroupBy, Template: c.Template}
+    err = layout.loadTemplate()
+    if err != nil {
+        return err
+    }
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, c.From, c.To, &c.Filter)
+    if err != nil {
+        return err
+    }
+    err = c.Redact.apply(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+
+    dates := make([]string, 0)
+    for date := range entriesGroupedByDate {
+        if c.wantsDate(date) {
+            dates = append(dates, date)
+        }
+    }
+    sort.Strings(dates)
+    periods, err := layout.periodsFor(dates)
+    if err != nil {
+        return err
+    }
+    documents := make(map[string]NotionDocument)
+    pageDates := make(map[string]string)
+    entryCounts := make(map[string]int)
+    titles := make([]string, 0)
+    for _, period := range periods {
+        for _, group := range layout.pageGroups(period, entriesGroupedByDate) {
+            document, err := newNotionDocument(group, layout)
+            if err != nil {
+                return err
+            }
+            if _, ok := documents[document.Title()]; !ok {
+                titles = append(titles, document.Title())
+            }
+            documents[document.Title()] = document
+            pageDates[document.Title()] = group.Date
+            entryCounts[document.Title()] += len(group.Entries)
+        }
+    }
+
+    api := notionAPI{key: c.NotionKey, httpClient: c.HttpClient}
+    pages, err := api.queryDatabase(c.DBID, nil)
+    if err != nil {
+        return err
+    }
+    pagesByTitle := make(map[string][]NotionPage)
+    for _, page := range pages {
+        title := page.Title()
+        from, to, ok := page.Dates()
+        _, expected := documents[title]
+        if page.Archived || !(expected || ok && c.overlaps(from, to)) {
+            continue
+        }
+        if _, seen := pageDates[title]; !seen {
+            titles = append(titles, title)
+            pageDates[title] = from
+        }
+        pagesByTitle[title] = append(pagesByTitle[title], page)
+    }
+    sort.SliceStable(titles, func(i, j int) bool {
+        if pageDates[titles[i]] != pageDates[titles[j]] {
+            return pageDates[titles[i]] < pageDates[titles[j]]
+        }
+        return titles[i] < titles[j]
+    })
+
+    grouped := c.GroupBy == GroupByWeek || c.GroupBy == GroupByMonth
+    problems := 0
+    for _, title := range titles {
+        document, expected := documents[title]
+        titlePages := pagesByTitle[title]
+        switch {
+        case len(titlePages) == 0:
+            fmt.Fprintf(c.out(), "%s: missing from notion (%d entries in jrnl)\n", title, entryCounts[title])
+            problems++
+            continue
+        case !expected:
+            fmt.Fprintf(c.out(), "%s: only in notion, jrnl has no entries for this day\n", title)
+            problems++
+            continue
+        case len(titlePages) > 1:
+            fmt.Fprintf(c.out(), "%s: duplicated, found %d pages in notion\n", title, len(titlePages))
+            problems++
+        }
+
+        want := c.textsInRange(document.Children, grouped)
+        for _, page := range titlePages {
+            blocks, err := api.blockChildren(page.ID)
+            if err != nil {
+                return err
+            }
+            have := c.textsInRange(blocks, grouped)
+            if !equalTexts(want, have) {
+                fmt.Fprintf(c.out(), "%s: content differs, jrnl has %d blocks and notion page %s has %d\n", title, len(want), page.ID, len(have))
+                problems++
+            }
+        }
+    }
+
+    fmt.Fprintf(c.out(), "Checked %d pages, found %d problems\n", len(titles), problems)
+    if problems > 0 {
+        return fmt.Errorf("%w%d problems found", ErrBackupDrift, problems)
+    }
+    return nil
+}
+
+func (c *VerifyConfig) wantsDate(date string) bool {
+    if !isEntryDate(date) {
+        return false
+    }
+    return (c.From == "" || date >= c.From) && (c.To == "" || date <= c.To)
+}
+
+func (c *VerifyConfig) overlaps(from, to string) bool {
+    return (c.From == "" || to >= c.From) && (c.To == "" || from <= c.To)
+}
+
+// textsInRange leaves out the days of a week or month page outside -from and
+// -to, they're checked when the range covers them
+func (c *VerifyConfig) textsInRange(blocks []Block, grouped bool) []string {
+    texts := make([]string, 0, len(blocks))
+    for _, section := range splitSections(blocks, grouped) {
+        if section.Date != "" && !c.wantsDate(section.Date) {
+            continue
+        }
+        if section.Heading != nil {
+            texts = append(texts, normalizeEntryText(section.Heading.PlainText()))
+        }
+        texts = append(texts, notionDocumentTexts(section.Blocks)...)
+    }
+    return texts
+}
+
+func (c *VerifyConfig) out() io.Writer {
+    if c.Out == nil {
+        return io.Discard
+    }
+    return c.Out
+}
+
+func notionDocumentTexts(blocks []Block) []string {
+    texts := make([]string, 0, len(blocks))
+    for _, b := range blocks {
+        text := normalizeEntryText(b.PlainText())
+        if text != "" {
+            texts = append(texts, text)
+        }
+    }
+    return texts
+}
+
+func equalTexts(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,165 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+func TestVerifyPassesWhenEveryDayIsBackedUp(t *testing.T) {
+    notion := newFakeNotion()
+    notion.pageSize = 1
+    jrnlOutput := jrnlOutputFixture(t)
+    for _, date := range []string{"2021-11-23", "2021-11-24", "2021-11-25"} {
+        config := sync.Config{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: jrnlOutput},
+            DateForEntries: date,
+        }
+        err := config.Exec(context.Background(), []string{})
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    out := &bytes.Buffer{}
+    config := sync.VerifyConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutput},
+        Out: out,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Errorf("expected no drift, got %s: %s", err, out.String())
+    }
+    if !strings.Contains(out.String(), "Checked 3 pages, found 0 problems") {
+        t.Errorf("expected a summary of the check, got %q", out.String())
+    }
+}
+
+func TestVerifyReportsMissingDuplicatedAndDifferentDays(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "the last one", "next one")
+    notion.addPage("2021-11-25", "Too late")
+    notion.addPage("2021-11-25", "Too late")
+    notion.addPage("2021-11-26", "Written on my phone")
+    notion.addPage("2021-12-01", "Outside the range")
+
+    out := &bytes.Buffer{}
+    config := sync.VerifyConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+        To: "2021-11-30",
+        Out: out,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrBackupDrift) {
+        t.Errorf("Expected error to be of type ErrBackupDrift, got %+v", err)
+    }
+
+    expectedLines := []string{
+        "2021-11-23: missing from notion (1 entries in jrnl)",
+        "2021-11-24: content differs, jrnl has 3 blocks and notion page page-1 has 2",
+        "2021-11-25: duplicated, found 2 pages in notion",
+        "2021-11-26: only in notion, jrnl has no entries for this day",
+        "Checked 4 pages, found 4 problems",
+    }
+    for _, line := range expectedLines {
+        if !strings.Contains(out.String(), line) {
+            t.Errorf("expected report to contain %q, got %q", line, out.String())
+        }
+    }
+    if strings.Contains(out.String(), "2021-12-01") {
+        t.Errorf("expected dates outside the range to be ignored, got %q", out.String())
+    }
+}
+
+func TestVerifyReturnsErrWhenFailingToQueryNotion(t *testing.T) {
+    config := sync.VerifyConfig{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: &mockHTTPClient{errOnDo: true},
+        Cmd: mockCommand{outputString: jrnlOutputFixture(t)},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrPostingToNotion) {
+        t.Errorf("Expected error to be of type ErrPostingToNotion, got %+v", err)
+    }
+}
+
+func TestVerifyChecksPagesWithTheTemplateAndGroupBySyncUsed(t *testing.T) {
+    notion := newFakeNotion()
+    templateFile := writePageTemplate(t)
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: weekJrnlOutput},
+        DateForEntries: "2021-11-24",
+        GroupBy: sync.GroupByWeek,
+        Template: templateFile,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    testCases := []struct{
+        name string
+        groupBy string
+        template string
+        expectedOutput string
+    }{
+        {name: "with the layout sync used", groupBy: sync.GroupByWeek, template: templateFile, expectedOutput: "Checked 1 pages, found 0 problems"},
+        {name: "with another layout", groupBy: sync.GroupByDay, expectedOutput: "2021-11-22: missing from notion (1 entries in jrnl)"},
+    }
+    for _, testCase := range testCases {
+        out := &bytes.Buffer{}
+        verify := sync.VerifyConfig{
+            DBID: "mockdbid",
+            NotionKey: "fakeNotionKey",
+            HttpClient: notion,
+            Cmd: mockCommand{outputString: weekJrnlOutput},
+            To: "2021-11-24",
+            GroupBy: testCase.groupBy,
+            Template: testCase.template,
+            Out: out,
+        }
+        verify.Exec(context.Background(), []string{})
+        if !strings.Contains(out.String(), testCase.expectedOutput) {
+            t.Errorf("%s: expected report to contain %q, got %q", testCase.name, testCase.expectedOutput, out.String())
+        }
+    }
+}
+
+const weekJrnlOutput = `{"tags": {}, "entries": [
+    {"title": "Monday run.", "body": "Ran 5k", "date": "2021-11-22", "time": "08:00", "tags": []},
+    {"title": "Standup.", "body": "Talked.", "date": "2021-11-24", "time": "09:00", "tags": ["@work"]}
+]}`
+
+func writePageTemplate(t *testing.T) string {
+    templateFile := filepath.Join(t.TempDir(), "page.tmpl")
+    err := os.WriteFile(templateFile, []byte(`
+{{define "title"}}Week of {{.Date.Format "02 Jan 2006"}}{{end}}
+{{define "entry"}}
+{{- heading3 (printf "%s %s" .Time .Title) -}}
+{{- paragraph .Body -}}
+{{- with .Tags}}Tags: {{join . ", "}}{{end -}}
+{{end}}`), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return templateFile
+}
@@ -0,0 +1,35 @@
+package sync
+
+import (
+	"flag"
+
+	"github.com/jm96441n/jrnlSync/profile"
+)
+
+type NotionProfile struct {
+    Name string
+    File string
+}
+
+func (p *NotionProfile) RegisterFlags(fs *flag.FlagSet) {
+    fs.StringVar(&p.Name, "profile", "", "Read the notion database id and key from this profile instead of -d and -k")
+    fs.StringVar(&p.File, "profiles-file", profile.DefaultPath(), "The file your profiles are saved in")
+}
+
+// fill sets dbID and key from the profile when they weren't given as flags.
+func (p NotionProfile) fill(dbID, key *string) error {
+    if p.Name == "" {
+        return nil
+    }
+    loaded, err := profile.NewStore(p.File).Load(p.Name)
+    if err != nil {
+        return err
+    }
+    if *dbID == "" {
+        *dbID = loaded.NotionDBID
+    }
+    if *key == "" {
+        *key, err = loaded.Key()
+    }
+    return err
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+    RedactActionMask = "mask"
+    RedactActionHash = "hash"
+    RedactActionRemove = "remove"
+)
+
+var builtinRedactionPatterns = map[string]string{
+    "email": `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+    "card": `\b\d(?:[ -]?\d){12,18}\b`,
+    "phone": `(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b`,
+}
+
+// cards are redacted before phone numbers so part of a card number is never
+// left behind looking like a phone number
+var builtinRedactionOrder = []string{"email", "card", "phone"}
+
+type RedactionRule struct {
+    Name string `yaml:"name"`
+    Pattern string `yaml:"pattern"`
+    Action string `yaml:"action"`
+}
+
+type redactionRulesFile struct {
+    HashKey string `yaml:"hash_key"`
+    Rules []RedactionRule `yaml:"rules"`
+}
+
+type Redactor struct {
+    Builtins tagList
+    Action string
+    RulesFile string
+    HashKey string
+    Out io.Writer
+}
+
+type compiledRedactionRule struct {
+    RedactionRule
+    regex *regexp.Regexp
+}
+
+var ErrUnknownRedactionRule = errors.New("unknown redaction rule, use email, phone or card: ")
+var ErrUnknownRedactAction = errors.New("unknown redaction action, use mask, hash or remove: ")
+var ErrInvalidRedactionRules = errors.New("failed to load the redaction rules: ")
+var ErrMissingRedactHashKey = errors.New("the hash redaction action needs a key, set -redact-hash-key or hash_key in the rules file: ")
+
+func (r *Redactor) RegisterFlags(fs *flag.FlagSet) {
+    fs.Var(&r.Builtins, "redact", "Redact emails, phone numbers or card numbers before sending, e.g. email,phone,card")
+    fs.StringVar(&r.Action, "redact-action", RedactActionMask, "How -redact rules redact what they find, mask, hash or remove")
+    fs.StringVar(&r.RulesFile, "redact-rules", "", "A yaml file with your own redaction rules")
+    fs.StringVar(&r.HashKey, "redact-hash-key", "", "The secret key the hash redaction action uses, overrides hash_key in the rules file")
+}
+
+func (r *Redactor) rules() ([]compiledRedactionRule, string, error) {
+    rules := make([]RedactionRule, 0)
+    action := r.Action
+    if action == "" {
+        action = RedactActionMask
+    }
+    wanted := make(map[string]bool)
+    for _, name := range r.Builtins {
+        name = strings.ToLower(name)
+        if _, ok := builtinRedactionPatterns[name]; !ok {
+            return nil, "", fmt.Errorf("%w%q", ErrUnknownRedactionRule, name)
+        }
+        wanted[name] = true
+    }
+    for _, name := range builtinRedactionOrder {
+        if wanted[name] {
+            rules = append(rules, RedactionRule{Name: name, Pattern: builtinRedactionPatterns[name], Action: action})
+        }
+    }
+
+    hashKey := r.HashKey
+    if r.RulesFile != "" {
+        contents, err := os.ReadFile(r.RulesFile)
+        if err != nil {
+            return nil, "", fmt.Errorf("%w%s", ErrInvalidRedactionRules, err)
+        }
+        file := redactionRulesFile{}
+        err = yaml.UnmarshalStrict(contents, &file)
+        if err != nil {
+            return nil, "", fmt.Errorf("%w%s", ErrInvalidRedactionRules, err)
+        }
+        if hashKey == "" {
+            hashKey = file.HashKey
+        }
+        rules = append(rules, file.Rules...)
+    }
+
+    compiled := make([]compiledRedactionRule, 0, len(rules))
+    for _, rule := range rules {
+        switch rule.Action {
+        case "":
+            rule.Action = RedactActionMask
+        case RedactActionMask, RedactActionHash, RedactActionRemove:
+        default:
+            return nil, "", fmt.Errorf("%w%q", ErrUnknownRedactAction, rule.Action)
+        }
+        if rule.Name == "" {
+            rule.Name = rule.Pattern
+        }
+        // without a key anyone can hash every phone number or card number and
+        // match them against the hashes
+        if rule.Action == RedactActionHash && hashKey == "" {
+            return nil, "", fmt.Errorf("%w%s", ErrMissingRedactHashKey, rule.Name)
+        }
+        regex, err := regexp.Compile(rule.Pattern)
+        if err != nil {
+            return nil, "", fmt.Errorf("%w%s: %s", ErrInvalidRedactionRules, rule.Name, err)
+        }
+        compiled = append(compiled, compiledRedactionRule{RedactionRule: rule, regex: regex})
+    }
+    return compiled, hashKey, nil
+}
+
+func (r *Redactor) apply(entriesGroupedByDate map[string][]Entry) error {
+    rules, hashKey, err := r.rules()
+    if err != nil || len(rules) == 0 {
+        return err
+    }
+
+    counts := make(map[string]map[string]int)
+    for date, entries := range entriesGroupedByDate {
+        counts[date] = make(map[string]int)
+        for i := range entries {
+            for _, rule := range rules {
+                entries[i].Title = rule.redact(entries[i].Title, hashKey, counts[date])
+                entries[i].Body = rule.redact(entries[i].Body, hashKey, counts[date])
+            }
+        }
+    }
+    r.report(counts)
+    return nil
+}
+
+func (rule compiledRedactionRule) redact(text, hashKey string, counts map[string]int) string {
+    return rule.regex.ReplaceAllStringFunc(text, func(match string) string {
+        counts[rule.Name]++
+        switch rule.Action {
+        case RedactActionRemove:
+            return ""
+        case RedactActionHash:
+            mac := hmac.New(sha256.New, []byte(hashKey))
+            mac.Write([]byte(match))
+            return fmt.Sprintf("[%s:%s]", rule.Name, hex.EncodeToString(mac.Sum(nil))[:12])
+        }
+        return fmt.Sprintf("[redacted %s]", rule.Name)
+    })
+}
+
+func (r *Redactor) report(counts map[string]map[string]int) {
+    out := r.Out
+    if out == nil {
+        out = os.Stderr
+    }
+    dates := make([]string, 0, len(counts))
+    for date := range counts {
+        dates = append(dates, date)
+    }
+    sort.Strings(dates)
+    for _, date := range dates {
+        total := 0
+        names := make([]string, 0, len(counts[date]))
+        for name, count := range counts[date] {
+            total += count
+            names = append(names, name)
+        }
+        sort.Strings(names)
+        details := make([]string, 0, len(names))
+        for _, name := range names {
+            details = append(details, fmt.Sprintf("%s %d", name, counts[date][name]))
+        }
+        if total == 0 {
+            fmt.Fprintf(out, "%s: 0 redactions\n", date)
+            continue
+        }
+        fmt.Fprintf(out, "%s: %d redactions (%s)\n", date, total, strings.Join(details, ", "))
+    }
+}
@@ -0,0 +1,237 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type RestoreConfig struct {
+    From string
+    DBID string
+    NotionKey string
+    Profile NotionProfile
+    ExportDir string
+    OutputFile string
+    DefaultTime string
+    GroupBy string
+    Template string
+    Force bool
+    HttpClient httpInteractor
+    Out io.Writer
+}
+
+var ErrUnknownRestoreSource = errors.New("unknown restore source: ")
+var ErrUnsupportedRestoreSource = errors.New("jrnlSync never syncs to this source so there's nothing to restore from: ")
+var ErrFailedToReadBackup = errors.New("failed to read the backup: ")
+var ErrRestoreOutputExists = errors.New("refusing to overwrite existing journal, use -force to replace it: ")
+var ErrFailedToWriteJournal = errors.New("failed to write the restored journal: ")
+
+// entryHeaderRegex matches the first line sync writes for an entry, the time is
+// in brackets unless a template wrote it
+var entryHeaderRegex = regexp.MustCompile(`^\[?(\d{1,2}:\d{2})\]? (.*?)( \*)?$`)
+
+func NewRestoreFlagSet(httpClient httpInteractor) *ffcli.Command {
+    c := &RestoreConfig{HttpClient: httpClient, Out: os.Stdout}
+    restoreFlagSet := flag.NewFlagSet("jrnlsync restore", flag.ExitOnError)
+    restoreFlagSet.StringVar(&c.From, "from", "", "Where to restore from, one of notion, export-dir or sqlite")
+    restoreFlagSet.StringVar(&c.DBID, "d", "", "The id of the notion database to restore from")
+    restoreFlagSet.StringVar(&c.NotionKey, "k", "", "Your notion integration key")
+    c.Profile.RegisterFlags(restoreFlagSet)
+    restoreFlagSet.StringVar(&c.ExportDir, "dir", "jrnlSite", "The directory written by the site command to restore from")
+    restoreFlagSet.StringVar(&c.OutputFile, "o", "journal.txt", "The jrnl journal file to write")
+    restoreFlagSet.StringVar(&c.DefaultTime, "time", "09:00", "The time (HH:MM) to give entries when the backup doesn't have one")
+    restoreFlagSet.BoolVar(&c.Force, "force", false, "Overwrite the journal file if it already exists")
+    registerLayoutFlags(restoreFlagSet, &c.GroupBy, &c.Template)
+
+    return &ffcli.Command{
+        Name:       "restore",
+        ShortUsage: "jrnlSync restore -from notion|export-dir|sqlite -o [JOURNAL_FILE]",
+        ShortHelp:  "Rebuilds a jrnl journal file from a backup made by jrnlSync",
+        FlagSet:    restoreFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *RestoreConfig) Exec(_ context.Context, _ []string) error {
+    err := c.Profile.fill(&c.DBID, &c.NotionKey)
+    if err != nil {
+        return err
+    }
+    var entries []Entry
+    switch c.From {
+    case "notion":
+        entries, err = c.entriesFromNotion()
+    case "export-dir":
+        entries, err = c.entriesFromExportDir()
+    case "sqlite":
+        return fmt.Errorf("%w%s", ErrUnsupportedRestoreSource, c.From)
+    default:
+        return fmt.Errorf("%w%q", ErrUnknownRestoreSource, c.From)
+    }
+    if err != nil {
+        return err
+    }
+
+    sort.SliceStable(entries, func(i, j int) bool {
+        if entries[i].Date != entries[j].Date {
+            return entries[i].Date < entries[j].Date
+        }
+        return entries[i].Time < entries[j].Time
+    })
+    for i := range entries {
+        if entries[i].Time == "" {
+            entries[i].Time = c.DefaultTime
+        }
+    }
+
+    buf := &bytes.Buffer{}
+    err = writeJrnlEntries(buf, entries)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteJournal, err)
+    }
+    flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+    if c.Force {
+        flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+    }
+    f, err := os.OpenFile(c.OutputFile, flags, 0600)
+    if errors.Is(err, os.ErrExist) {
+        return fmt.Errorf("%w%s", ErrRestoreOutputExists, c.OutputFile)
+    }
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteJournal, err)
+    }
+    _, err = f.Write(buf.Bytes())
+    if err != nil {
+        f.Close()
+        return fmt.Errorf("%w%s", ErrFailedToWriteJournal, err)
+    }
+    err = f.Close()
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteJournal, err)
+    }
+
+    fmt.Fprintf(c.out(), "Restored %d entries to %s\n", len(entries), c.OutputFile)
+    return nil
+}
+
+func (c *RestoreConfig) entriesFromNotion() ([]Entry, error) {
+    layout := &Config{GroupBy: c.GroupBy, Template: c.Template}
+    err := layout.loadTemplate()
+    if err != nil {
+        return nil, err
+    }
+    startType, err := entryStartType(layout)
+    if err != nil {
+        return nil, err
+    }
+    api := notionAPI{key: c.NotionKey, httpClient: c.HttpClient}
+    pages, err := api.queryDatabase(c.DBID, nil)
+    if err != nil {
+        return nil, err
+    }
+    entries := make([]Entry, 0)
+    for _, page := range pages {
+        if page.Archived {
+            continue
+        }
+        // jrnl needs a date for every entry, a page jrnlSync didn't write
+        // can't be given one
+        if _, _, ok := page.Dates(); !ok {
+            fmt.Fprintf(c.out(), "Skipping page %q, it has no %s and its title isn't a date\n", page.Title(), notionDateProperty)
+            continue
+        }
+        blocks, err := api.blockChildren(page.ID)
+        if err != nil {
+            return nil, err
+        }
+        for _, section := range datedSections(page, blocks) {
+            entries = append(entries, restoreEntries(section.Date, section.Blocks, startType, layout.GroupBy == GroupByEntry)...)
+        }
+    }
+    return entries, nil
+}
+
+// entryStartType is the type of the block each entry starts with when a
+// template lays them out, so the blocks of one entry can be put back together.
+// Without a template every block is an entry of its own.
+func entryStartType(layout *Config) (string, error) {
+    if layout.pageTemplate == nil {
+        return "", nil
+    }
+    blocks, err := layout.pageTemplate.entryBlocks(Entry{Title: "Title", Body: "Body", Date: "2006-01-02", Time: "15:04", Tags: []string{}})
+    if err != nil || len(blocks) == 0 {
+        return "", err
+    }
+    return blocks[0].Type, nil
+}
+
+// restoreEntries puts a day's blocks back together into entries, a new entry
+// starts at each block of startType (or every block) unless the page is one
+// entry.
+func restoreEntries(date string, blocks []Block, startType string, onePerPage bool) []Entry {
+    texts := make([][]string, 0)
+    for _, b := range blocks {
+        text := strings.TrimSpace(b.PlainText())
+        if text == "" {
+            continue
+        }
+        if len(texts) == 0 || (!onePerPage && (startType == "" || b.Type == startType)) {
+            texts = append(texts, []string{text})
+            continue
+        }
+        texts[len(texts)-1] = append(texts[len(texts)-1], text)
+    }
+    entries := make([]Entry, 0, len(texts))
+    for _, text := range texts {
+        entries = append(entries, parseEntryText(date, strings.Join(text, "\n\n")))
+    }
+    return entries
+}
+
+// parseEntryText reads the time, title and star back from the first line of an
+// entry sync wrote, text without one (like a note written in notion) is all
+// body.
+func parseEntryText(date, text string) Entry {
+    lines := strings.SplitN(text, "\n", 2)
+    match := entryHeaderRegex.FindStringSubmatch(strings.TrimSpace(lines[0]))
+    if match == nil {
+        return Entry{Date: date, Body: text}
+    }
+    e := Entry{Date: date, Time: match[1], Title: match[2], Starred: match[3] != ""}
+    if len(lines) == 2 {
+        e.Body = strings.TrimSpace(lines[1])
+    }
+    return e
+}
+
+func (c *RestoreConfig) entriesFromExportDir() ([]Entry, error) {
+    contents, err := os.ReadFile(filepath.Join(c.ExportDir, "search.json"))
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToReadBackup, err)
+    }
+    backup := JrnlBody{}
+    err = json.Unmarshal(contents, &backup)
+    if err != nil {
+        return nil, fmt.Errorf("%w%s", ErrFailedToReadBackup, err)
+    }
+    return backup.Entries, nil
+}
+
+func (c *RestoreConfig) out() io.Writer {
+    if c.Out == nil {
+        return io.Discard
+    }
+    return c.Out
+}
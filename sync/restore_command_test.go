@@ -0,0 +1,207 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+func TestRestoreFromExportDirRebuildsTheJournal(t *testing.T) {
+    dir := t.TempDir()
+    site := sync.SiteConfig{
+        OutputDir: filepath.Join(dir, "site"),
+        Title: "My Journal",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err := site.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    config := sync.RestoreConfig{
+        From: "export-dir",
+        ExportDir: site.OutputDir,
+        OutputFile: filepath.Join(dir, "journal.txt"),
+        DefaultTime: "09:00",
+    }
+    err = config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    journal, err := os.ReadFile(config.OutputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "[2021-10-31 09:00] Standup.\nTalked about <b>the</b> release.\n\n" +
+        "[2021-11-24 16:00] Retro.\nWent well.\n\n" +
+        "[2021-11-24 18:30] Dinner. *\nMade soup.\n\n"
+    if string(journal) != expected {
+        t.Errorf("expected restored journal to be %q, got %q", expected, string(journal))
+    }
+}
+
+func TestRestoreFromNotionRebuildsTheJournal(t *testing.T) {
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "[18:30] The new one @work *\nWith a body.", "written in notion")
+    notion.addPage("2021-11-23", "[07:00] Too early\nwith a second line\n\nand a third")
+
+    config := sync.RestoreConfig{
+        From: "notion",
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        OutputFile: filepath.Join(t.TempDir(), "journal.txt"),
+        DefaultTime: "09:00",
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    journal, err := os.ReadFile(config.OutputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "[2021-11-23 07:00] Too early\nwith a second line\n\nand a third\n\n" +
+        "[2021-11-24 09:00] written in notion\n\n" +
+        "[2021-11-24 18:30] The new one @work *\nWith a body.\n\n"
+    if string(journal) != expected {
+        t.Errorf("expected restored journal to be %q, got %q", expected, string(journal))
+    }
+}
+
+func TestRestoreDatesEntriesFromTheJournalDateAndSkipsUndatedPages(t *testing.T) {
+    notion := newFakeNotion()
+    week := notion.addPage("Week of 22 Nov 2021", "Ran 5k", "2021-11-24", "Talked.")
+    week.page.Properties.Date = &sync.NotionDateProperty{Date: &sync.NotionDate{Start: "2021-11-22", End: "2021-11-28"}}
+    week.blocks[1].Type = "heading_2"
+    week.blocks[1].Heading2, week.blocks[1].BulletedList = week.blocks[1].BulletedList, nil
+    notion.addPage("Ideas", "Not a journal entry")
+
+    out := &bytes.Buffer{}
+    config := sync.RestoreConfig{
+        From: "notion",
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        OutputFile: filepath.Join(t.TempDir(), "journal.txt"),
+        DefaultTime: "09:00",
+        Out: out,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    journal, err := os.ReadFile(config.OutputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "[2021-11-22 09:00] Ran 5k\n\n" +
+        "[2021-11-24 09:00] Talked.\n\n"
+    if string(journal) != expected {
+        t.Errorf("expected restored journal to be %q, got %q", expected, string(journal))
+    }
+    if !strings.Contains(out.String(), `Skipping page "Ideas"`) {
+        t.Errorf("expected the undated page to be reported, got %q", out.String())
+    }
+}
+
+func TestRestorePutsEntriesLaidOutWithATemplateBackTogether(t *testing.T) {
+    notion := newFakeNotion()
+    templateFile := writePageTemplate(t)
+    config := sync.Config{
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        Cmd: mockCommand{outputString: weekJrnlOutput},
+        DateForEntries: "2021-11-24",
+        GroupBy: sync.GroupByWeek,
+        Template: templateFile,
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    restore := sync.RestoreConfig{
+        From: "notion",
+        DBID: "mockdbid",
+        NotionKey: "fakeNotionKey",
+        HttpClient: notion,
+        OutputFile: filepath.Join(t.TempDir(), "journal.txt"),
+        DefaultTime: "09:00",
+        GroupBy: sync.GroupByWeek,
+        Template: templateFile,
+    }
+    err = restore.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    journal, err := os.ReadFile(restore.OutputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "[2021-11-22 08:00] Monday run.\nRan 5k\n\n" +
+        "[2021-11-24 09:00] Standup.\nTalked.\n\nTags: @work\n\n"
+    if string(journal) != expected {
+        t.Errorf("expected restored journal to be %q, got %q", expected, string(journal))
+    }
+}
+
+func TestRestoreReturnsErrWhenTheJournalAlreadyExists(t *testing.T) {
+    dir := t.TempDir()
+    outputFile := filepath.Join(dir, "journal.txt")
+    err := os.WriteFile(outputFile, []byte("precious"), 0600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    notion := newFakeNotion()
+    notion.addPage("2021-11-24", "The new one")
+
+    config := sync.RestoreConfig{
+        From: "notion",
+        HttpClient: notion,
+        OutputFile: outputFile,
+        DefaultTime: "09:00",
+    }
+    err = config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrRestoreOutputExists) {
+        t.Errorf("Expected error to be of type ErrRestoreOutputExists, got %+v", err)
+    }
+    journal, err := os.ReadFile(outputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(journal) != "precious" {
+        t.Errorf("expected existing journal to be left alone, got %q", string(journal))
+    }
+}
+
+func TestRestoreReturnsErrForSourcesItCannotRestoreFrom(t *testing.T) {
+    testCases := []struct {
+        from string
+        expectedError error
+    }{
+        {from: "sqlite", expectedError: sync.ErrUnsupportedRestoreSource},
+        {from: "dropbox", expectedError: sync.ErrUnknownRestoreSource},
+    }
+    for _, testCase := range testCases {
+        config := sync.RestoreConfig{
+            From: testCase.from,
+            OutputFile: filepath.Join(t.TempDir(), "journal.txt"),
+        }
+        err := config.Exec(context.Background(), []string{})
+        if !errors.Is(err, testCase.expectedError) {
+            t.Errorf("Expected error for %s to be %q, got %q", testCase.from, testCase.expectedError, err)
+        }
+    }
+}
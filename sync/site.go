@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+type siteDay struct {
+    Date string
+    Path string
+    Entries []Entry
+}
+
+type siteMonth struct {
+    Name string
+    Count int
+    Days []*siteDay
+}
+
+type siteYear struct {
+    Year string
+    Months []*siteMonth
+}
+
+type siteTag struct {
+    Name string
+    Slug string
+    Entries []Entry
+}
+
+type sitePage struct {
+    SiteTitle string
+    PageTitle string
+    Root string
+    Years []*siteYear
+    Entries []Entry
+    Previous *siteDay
+    Next *siteDay
+    Tags []*siteTag
+}
+
+type siteEntry struct {
+    Entry
+    Root string
+}
+
+func parseSiteTemplates(tags []*siteTag) (*template.Template, error) {
+    slugs := make(map[string]string, len(tags))
+    for _, tag := range tags {
+        slugs[tag.Name] = tag.Slug
+    }
+    funcs := template.FuncMap{
+        "paragraphs": paragraphs,
+        "tagSlug": func(name string) string {
+            if slug, ok := slugs[name]; ok {
+                return slug
+            }
+            return tagSlug(name)
+        },
+        "dayPath": dayPath,
+        "withRoot": func(root string, e Entry) siteEntry {
+            return siteEntry{Entry: e, Root: root}
+        },
+    }
+    return template.New("site").Funcs(funcs).ParseFS(templateFiles, "templates/site.html.tmpl")
+}
+
+func newSiteDays(entriesGroupedByDate map[string][]Entry) []*siteDay {
+    days := make([]*siteDay, 0, len(entriesGroupedByDate))
+    for date, entries := range entriesGroupedByDate {
+        days = append(days, &siteDay{Date: date, Path: dayPath(date), Entries: entries})
+    }
+    sort.Slice(days, func(i, j int) bool {
+        return days[i].Date < days[j].Date
+    })
+    return days
+}
+
+func newSiteYears(days []*siteDay) []*siteYear {
+    years := make([]*siteYear, 0)
+    for i := len(days) - 1; i >= 0; i-- {
+        day := days[i]
+        year, monthName := day.Date, day.Date
+        if t, err := time.Parse("2006-01-02", day.Date); err == nil {
+            year, monthName = t.Format("2006"), t.Format("January")
+        }
+        if len(years) == 0 || years[len(years)-1].Year != year {
+            years = append(years, &siteYear{Year: year})
+        }
+        y := years[len(years)-1]
+        if len(y.Months) == 0 || y.Months[len(y.Months)-1].Name != monthName {
+            y.Months = append(y.Months, &siteMonth{Name: monthName})
+        }
+        m := y.Months[len(y.Months)-1]
+        m.Days = append(m.Days, day)
+        m.Count += len(day.Entries)
+    }
+    return years
+}
+
+func newSiteTags(days []*siteDay) []*siteTag {
+    byName := make(map[string]*siteTag)
+    tags := make([]*siteTag, 0)
+    for _, day := range days {
+        for _, e := range day.Entries {
+            for _, name := range e.Tags {
+                tag, ok := byName[name]
+                if !ok {
+                    tag = &siteTag{Name: name}
+                    byName[name] = tag
+                    tags = append(tags, tag)
+                }
+                tag.Entries = append(tag.Entries, e)
+            }
+        }
+    }
+    sort.Slice(tags, func(i, j int) bool {
+        if strings.ToLower(tags[i].Name) != strings.ToLower(tags[j].Name) {
+            return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+        }
+        return tags[i].Name < tags[j].Name
+    })
+
+    // tags like @Work and @work slugify to the same name, later ones get a
+    // number so one tag page doesn't overwrite another
+    used := map[string]bool{"index": true}
+    for _, tag := range tags {
+        slug := tagSlug(tag.Name)
+        for n := 2; used[slug]; n++ {
+            slug = fmt.Sprintf("%s-%d", tagSlug(tag.Name), n)
+        }
+        used[slug] = true
+        tag.Slug = slug
+    }
+    return tags
+}
+
+func dayPath(date string) string {
+    parts := strings.Split(date, "-")
+    if len(parts) != 3 {
+        return fmt.Sprintf("%s.html", date)
+    }
+    return fmt.Sprintf("%s/%s/%s.html", parts[0], parts[1], parts[2])
+}
+
+func tagSlug(tag string) string {
+    var slug strings.Builder
+    for _, r := range strings.ToLower(tag) {
+        switch {
+        case r == '@':
+            slug.WriteString("at-")
+        case r == '#':
+            slug.WriteString("hash-")
+        case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-':
+            slug.WriteRune(r)
+        default:
+            slug.WriteRune('-')
+        }
+    }
+    return slug.String()
+}
+
+func paragraphs(body string) []template.HTML {
+    paras := make([]template.HTML, 0)
+    for _, p := range strings.Split(strings.TrimSpace(body), "\n\n") {
+        if strings.TrimSpace(p) == "" {
+            continue
+        }
+        lines := strings.Split(strings.TrimSpace(p), "\n")
+        for i, l := range lines {
+            lines[i] = template.HTMLEscapeString(l)
+        }
+        paras = append(paras, template.HTML(strings.Join(lines, "<br>\n")))
+    }
+    return paras
+}
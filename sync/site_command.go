@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type SiteConfig struct {
+    OutputDir string
+    Title string
+    Cmd commandOutputter
+    Filter EntryFilter
+    Redact Redactor
+}
+
+var ErrFailedToRenderSite = errors.New("failed to render the journal site: ")
+var ErrFailedToWriteSite = errors.New("failed to write the journal site: ")
+
+func NewSiteFlagSet(cmd commandOutputter) *ffcli.Command {
+    c := &SiteConfig{Cmd: cmd}
+    siteFlagSet := flag.NewFlagSet("jrnlsync site", flag.ExitOnError)
+    siteFlagSet.StringVar(&c.OutputDir, "o", "jrnlSite", "The directory to write the site to")
+    siteFlagSet.StringVar(&c.Title, "title", "Journal", "The title shown at the top of every page")
+    c.Filter.RegisterFlags(siteFlagSet)
+    c.Redact.RegisterFlags(siteFlagSet)
+    registerSourceFlags(siteFlagSet, cmd)
+
+    return &ffcli.Command{
+        Name:       "site",
+        ShortUsage: "jrnlSync site -o [OUTPUT_DIR]",
+        ShortHelp:  "Renders all of your notes into a static html site you can browse offline",
+        FlagSet:    siteFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *SiteConfig) Exec(_ context.Context, _ []string) error {
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, "", "", &c.Filter)
+    if err != nil {
+        return err
+    }
+    err = c.Redact.apply(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+    days := newSiteDays(entriesGroupedByDate)
+    tags := newSiteTags(days)
+    tmpl, err := parseSiteTemplates(tags)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToRenderSite, err)
+    }
+
+    err = c.render(tmpl, "index", "index.html", sitePage{PageTitle: c.Title, Years: newSiteYears(days)})
+    if err != nil {
+        return err
+    }
+    for i, day := range days {
+        page := sitePage{PageTitle: day.Date, Root: "../../", Entries: day.Entries}
+        if i > 0 {
+            page.Previous = days[i-1]
+        }
+        if i < len(days)-1 {
+            page.Next = days[i+1]
+        }
+        err = c.render(tmpl, "day", day.Path, page)
+        if err != nil {
+            return err
+        }
+    }
+    err = c.render(tmpl, "tags", filepath.Join("tags", "index.html"), sitePage{PageTitle: "Tags", Root: "../", Tags: tags})
+    if err != nil {
+        return err
+    }
+    for _, tag := range tags {
+        page := sitePage{PageTitle: tag.Name, Root: "../", Entries: tag.Entries}
+        err = c.render(tmpl, "tag", filepath.Join("tags", fmt.Sprintf("%s.html", tag.Slug)), page)
+        if err != nil {
+            return err
+        }
+    }
+    err = c.render(tmpl, "search", "search.html", sitePage{PageTitle: "Search"})
+    if err != nil {
+        return err
+    }
+
+    return c.writeAssets(days)
+}
+
+func (c *SiteConfig) render(tmpl *template.Template, name, path string, page sitePage) error {
+    page.SiteTitle = c.Title
+    buf := &bytes.Buffer{}
+    err := tmpl.ExecuteTemplate(buf, name, page)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToRenderSite, err)
+    }
+    return c.writeFile(path, buf.Bytes())
+}
+
+func (c *SiteConfig) writeAssets(days []*siteDay) error {
+    style, err := templateFiles.ReadFile("templates/style.css")
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToRenderSite, err)
+    }
+    err = c.writeFile("style.css", style)
+    if err != nil {
+        return err
+    }
+
+    index := JrnlBody{Entries: make([]Entry, 0)}
+    for _, day := range days {
+        index.Entries = append(index.Entries, day.Entries...)
+    }
+    indexJSON, err := json.Marshal(index)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToRenderSite, err)
+    }
+    err = c.writeFile("search.json", indexJSON)
+    if err != nil {
+        return err
+    }
+    return c.writeFile("search.js", []byte(fmt.Sprintf("var journalSearchIndex = %s;\n", indexJSON)))
+}
+
+// writeFile keeps the site readable only by you, it's a copy of a private journal
+func (c *SiteConfig) writeFile(path string, contents []byte) error {
+    fullPath := filepath.Join(c.OutputDir, path)
+    err := os.MkdirAll(filepath.Dir(fullPath), 0700)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteSite, err)
+    }
+    err = os.WriteFile(fullPath, contents, 0600)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteSite, err)
+    }
+    return nil
+}
@@ -0,0 +1,168 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+const siteJrnlOutput = `{
+    "tags": {"@work": 2, "@home": 1},
+    "entries": [
+        {"title": "Standup.", "body": "Talked about <b>the</b> release.", "date": "2021-10-31", "time": "09:00", "tags": ["@work"], "starred": false},
+        {"title": "Dinner.", "body": "Made soup.", "date": "2021-11-24", "time": "18:30", "tags": ["@home"], "starred": true},
+        {"title": "Retro.", "body": "Went well.", "date": "2021-11-24", "time": "16:00", "tags": ["@work"], "starred": false}
+    ]
+}`
+
+func TestSiteExecWritesAPagePerDayWithIndexTagsAndSearch(t *testing.T) {
+    dir := t.TempDir()
+    config := sync.SiteConfig{
+        OutputDir: dir,
+        Title: "My Journal",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedContents := map[string][]string{
+        "index.html": {"My Journal", "2021", "October", "November", `href="2021/11/24.html"`},
+        "2021/10/31.html": {"Standup.", "Talked about &lt;b&gt;the&lt;/b&gt; release.", `href="../../tags/at-work.html"`, `href="../../2021/11/24.html"`},
+        "2021/11/24.html": {"Dinner.", "Retro.", "★", `href="../../2021/10/31.html"`},
+        "tags/index.html": {`href="at-work.html"`, `href="at-home.html"`},
+        "tags/at-work.html": {"Standup.", "Retro."},
+        "search.html": {"search.js"},
+        "style.css": {"body"},
+    }
+    for path, snippets := range expectedContents {
+        contents, err := os.ReadFile(filepath.Join(dir, path))
+        if err != nil {
+            t.Errorf("expected %s to be written: %s", path, err)
+            continue
+        }
+        for _, snippet := range snippets {
+            if !strings.Contains(string(contents), snippet) {
+                t.Errorf("expected %s to contain %q", path, snippet)
+            }
+        }
+    }
+
+    searchIndex, err := os.ReadFile(filepath.Join(dir, "search.json"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    index := sync.JrnlBody{}
+    err = json.Unmarshal(searchIndex, &index)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(index.Entries) != 3 {
+        t.Errorf("expected 3 entries in the search index, got %d", len(index.Entries))
+    }
+}
+
+func TestSiteExecGivesTagsThatSlugifyTheSameTheirOwnPages(t *testing.T) {
+    dir := t.TempDir()
+    config := sync.SiteConfig{
+        OutputDir: dir,
+        Title: "My Journal",
+        Cmd: mockCommand{outputString: `{"entries": [
+            {"title": "Standup.", "body": "", "date": "2021-11-24", "time": "09:00", "tags": ["@work"]},
+            {"title": "Retro.", "body": "", "date": "2021-11-24", "time": "16:00", "tags": ["@Work"]}
+        ]}`},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expectedContents := map[string]string{
+        "tags/at-work.html": "Retro.",
+        "tags/at-work-2.html": "Standup.",
+        "tags/index.html": `href="at-work-2.html"`,
+        "2021/11/24.html": `href="../../tags/at-work-2.html"`,
+    }
+    for path, snippet := range expectedContents {
+        contents, err := os.ReadFile(filepath.Join(dir, path))
+        if err != nil {
+            t.Errorf("expected %s to be written: %s", path, err)
+            continue
+        }
+        if !strings.Contains(string(contents), snippet) {
+            t.Errorf("expected %s to contain %q", path, snippet)
+        }
+    }
+
+    for path, expected := range map[string]os.FileMode{"tags": 0700, "tags/at-work.html": 0600} {
+        info, err := os.Stat(filepath.Join(dir, path))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if info.Mode().Perm() != expected {
+            t.Errorf("expected %s to have permissions %s, got %s", path, expected, info.Mode().Perm())
+        }
+    }
+}
+
+func TestSiteExecShowsThePlaceholderOnceForPrivateEntries(t *testing.T) {
+    dir := t.TempDir()
+    config := sync.SiteConfig{
+        OutputDir: dir,
+        Title: "My Journal",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+        Filter: sync.EntryFilter{PrivateTag: "@home", PrivateAction: sync.PrivateActionPlaceholder, Placeholder: "Private entry"},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    contents, err := os.ReadFile(filepath.Join(dir, "2021/11/24.html"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if count := strings.Count(string(contents), "Private entry"); count != 1 {
+        t.Errorf("expected the placeholder once, got it %d times in %s", count, contents)
+    }
+    if strings.Contains(string(contents), "Made soup.") {
+        t.Errorf("expected the private entry not to be shown, got %s", contents)
+    }
+}
+
+func TestSiteExecReturnsErrWhenCommandFailsToRun(t *testing.T) {
+    config := sync.SiteConfig{
+        OutputDir: t.TempDir(),
+        Title: "My Journal",
+        Cmd: mockCommand{errOnOutput: true},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrJrnlCommandFailed) {
+        t.Errorf("Expected error to be of type ErrJrnlCommandFailed, got %+v", err)
+    }
+}
+
+func TestSiteExecReturnsErrWhenOutputDirCannotBeWritten(t *testing.T) {
+    dir := t.TempDir()
+    outputDir := filepath.Join(dir, "site")
+    err := os.WriteFile(outputDir, []byte("not a directory"), 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    config := sync.SiteConfig{
+        OutputDir: outputDir,
+        Title: "My Journal",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err = config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrFailedToWriteSite) {
+        t.Errorf("Expected error to be of type ErrFailedToWriteSite, got %+v", err)
+    }
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Timeline struct {
+    Title *TimelineSlide `json:"title,omitempty"`
+    Events []TimelineSlide `json:"events"`
+}
+
+type TimelineSlide struct {
+    StartDate *TimelineDate `json:"start_date,omitempty"`
+    Text TimelineText `json:"text"`
+    Group string `json:"group,omitempty"`
+    UniqueID string `json:"unique_id,omitempty"`
+}
+
+type TimelineDate struct {
+    Year string `json:"year"`
+    Month string `json:"month,omitempty"`
+    Day string `json:"day,omitempty"`
+    Hour string `json:"hour,omitempty"`
+    Minute string `json:"minute,omitempty"`
+}
+
+type TimelineText struct {
+    Headline string `json:"headline"`
+    Text string `json:"text,omitempty"`
+}
+
+func newTimeline(entries []Entry, title string) Timeline {
+    events := make([]TimelineSlide, 0, len(entries))
+    for i, e := range entries {
+        headline := e.Title
+        if e.Starred {
+            headline = fmt.Sprintf("★ %s", headline)
+        }
+        group := ""
+        if len(e.Tags) > 0 {
+            group = e.Tags[0]
+        }
+        events = append(events, TimelineSlide{
+            StartDate: newTimelineDate(e.Date, e.Time),
+            Text: TimelineText{
+                Headline: headline,
+                Text: paragraphsHTML(e.Body),
+            },
+            Group: group,
+            UniqueID: fmt.Sprintf("entry-%d", i+1),
+        })
+    }
+
+    return Timeline{
+        Title: &TimelineSlide{Text: TimelineText{Headline: title}},
+        Events: events,
+    }
+}
+
+func newTimelineDate(date, clock string) *TimelineDate {
+    d := &TimelineDate{}
+    dateParts := strings.SplitN(date, "-", 3)
+    d.Year = dateParts[0]
+    if len(dateParts) == 3 {
+        d.Month, d.Day = dateParts[1], dateParts[2]
+    }
+    timeParts := strings.SplitN(clock, ":", 2)
+    if len(timeParts) == 2 {
+        d.Hour, d.Minute = timeParts[0], timeParts[1]
+    }
+    return d
+}
+
+func paragraphsHTML(body string) string {
+    html := make([]string, 0)
+    for _, p := range paragraphs(body) {
+        html = append(html, fmt.Sprintf("<p>%s</p>", p))
+    }
+    return strings.Join(html, "\n")
+}
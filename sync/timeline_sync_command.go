@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+// TimelineJSVersion is the release of TimelineJS the html viewer loads, it's
+// pinned so a new release can't change or break a viewer that's already written
+const TimelineJSVersion = "3.8.12"
+
+type TimelineConfig struct {
+    OutputFile string
+    HTMLFile string
+    TimelineJSDir string
+    Title string
+    From string
+    To string
+    Cmd commandOutputter
+    Filter EntryFilter
+    Redact Redactor
+}
+
+var ErrFailedToWriteTimeline = errors.New("failed to write the timeline: ")
+
+func NewTimelineSyncFlagSet(cmd commandOutputter) *ffcli.Command {
+    c := &TimelineConfig{Cmd: cmd}
+    timelineFlagSet := flag.NewFlagSet("jrnlsync timeline", flag.ExitOnError)
+    timelineFlagSet.StringVar(&c.OutputFile, "o", "timeline.json", "The file to write the TimelineJS json to")
+    timelineFlagSet.StringVar(&c.HTMLFile, "html", "", "Also write a html page that shows the timeline to this file")
+    timelineFlagSet.StringVar(&c.TimelineJSDir, "timelinejs", "", "A directory with js/timeline.js and css/timeline.css from a TimelineJS release to put in the html page so it works offline")
+    timelineFlagSet.StringVar(&c.Title, "title", "Journal", "The title of the timeline")
+    timelineFlagSet.StringVar(&c.From, "from", "", "Only include entries on or after this date (YYYY-MM-DD)")
+    timelineFlagSet.StringVar(&c.To, "to", "", "Only include entries on or before this date (YYYY-MM-DD)")
+    c.Filter.RegisterFlags(timelineFlagSet)
+    c.Redact.RegisterFlags(timelineFlagSet)
+    registerSourceFlags(timelineFlagSet, cmd)
+
+    return &ffcli.Command{
+        Name:       "timeline",
+        ShortUsage: "jrnlSync timeline -o [OUTPUT_FILE] [-html HTML_FILE]",
+        ShortHelp:  "Writes your notes out as a TimelineJS timeline",
+        FlagSet:    timelineFlagSet,
+        Exec:       c.Exec,
+    }
+}
+
+func (c *TimelineConfig) Exec(_ context.Context, _ []string) error {
+    entriesGroupedByDate, err := getEntriesGroupedByDate(c.Cmd, c.From, c.To, &c.Filter)
+    if err != nil {
+        return err
+    }
+    err = c.Redact.apply(entriesGroupedByDate)
+    if err != nil {
+        return err
+    }
+    entries := make([]Entry, 0)
+    for _, entriesForDate := range entriesGroupedByDate {
+        entries = append(entries, entriesForDate...)
+    }
+    sort.SliceStable(entries, func(i, j int) bool {
+        if entries[i].Date != entries[j].Date {
+            return entries[i].Date < entries[j].Date
+        }
+        return entries[i].Time < entries[j].Time
+    })
+
+    timeline := newTimeline(entries, c.Title)
+    timelineJSON, err := json.MarshalIndent(timeline, "", "  ")
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+    }
+    err = os.WriteFile(c.OutputFile, timelineJSON, 0600)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+    }
+
+    if c.HTMLFile == "" {
+        return nil
+    }
+    return c.writeViewer(timeline)
+}
+
+func (c *TimelineConfig) writeViewer(timeline Timeline) error {
+    tmpl, err := template.ParseFS(templateFiles, "templates/timeline.html.tmpl")
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+    }
+    data := struct {
+        Title string
+        Timeline Timeline
+        Version string
+        Script template.JS
+        Style template.CSS
+    }{Title: c.Title, Timeline: timeline, Version: TimelineJSVersion}
+    if c.TimelineJSDir != "" {
+        script, err := os.ReadFile(filepath.Join(c.TimelineJSDir, "js", "timeline.js"))
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+        }
+        style, err := os.ReadFile(filepath.Join(c.TimelineJSDir, "css", "timeline.css"))
+        if err != nil {
+            return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+        }
+        data.Script, data.Style = template.JS(script), template.CSS(style)
+    }
+    buf := &bytes.Buffer{}
+    err = tmpl.Execute(buf, data)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+    }
+    err = os.WriteFile(c.HTMLFile, buf.Bytes(), 0600)
+    if err != nil {
+        return fmt.Errorf("%w%s", ErrFailedToWriteTimeline, err)
+    }
+    return nil
+}
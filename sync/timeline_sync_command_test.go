@@ -0,0 +1,127 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/jrnlSync/sync"
+)
+
+func TestTimelineExecWritesAnEventPerEntryInTheDateRange(t *testing.T) {
+    dir := t.TempDir()
+    config := sync.TimelineConfig{
+        OutputFile: filepath.Join(dir, "timeline.json"),
+        HTMLFile: filepath.Join(dir, "timeline.html"),
+        Title: "My Year",
+        From: "2021-11-01",
+        To: "2021-11-30",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    timelineJSON, err := os.ReadFile(config.OutputFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    timeline := sync.Timeline{}
+    err = json.Unmarshal(timelineJSON, &timeline)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if timeline.Title.Text.Headline != config.Title {
+        t.Errorf("expected timeline title to be %q, got %q", config.Title, timeline.Title.Text.Headline)
+    }
+    if len(timeline.Events) != 2 {
+        t.Fatalf("expected 2 events, got %d", len(timeline.Events))
+    }
+
+    first := timeline.Events[0]
+    expectedDate := sync.TimelineDate{Year: "2021", Month: "11", Day: "24", Hour: "16", Minute: "00"}
+    if *first.StartDate != expectedDate {
+        t.Errorf("expected first event to start at %+v, got %+v", expectedDate, *first.StartDate)
+    }
+    if first.Text.Headline != "Retro." || first.Group != "@work" {
+        t.Errorf("expected first event to be the retro grouped by @work, got %+v", first)
+    }
+    if timeline.Events[1].Text.Headline != "★ Dinner." {
+        t.Errorf("expected starred entry headline to be marked, got %q", timeline.Events[1].Text.Headline)
+    }
+
+    viewer, err := os.ReadFile(config.HTMLFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(string(viewer), "TL.Timeline") || !strings.Contains(string(viewer), "Retro.") {
+        t.Errorf("expected html viewer to load the timeline with its events")
+    }
+    if !strings.Contains(string(viewer), "timeline3/"+sync.TimelineJSVersion+"/js/timeline.js") {
+        t.Errorf("expected html viewer to load TimelineJS %s", sync.TimelineJSVersion)
+    }
+    for _, path := range []string{config.OutputFile, config.HTMLFile} {
+        info, err := os.Stat(path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if info.Mode().Perm() != 0600 {
+            t.Errorf("expected %s to have permissions 0600, got %o", path, info.Mode().Perm())
+        }
+    }
+}
+
+func TestTimelineExecPutsTimelineJSInTheViewerWhenGivenACopy(t *testing.T) {
+    dir := t.TempDir()
+    libDir := filepath.Join(dir, "timelinejs")
+    for path, contents := range map[string]string{"js/timeline.js": "var TL = {};", "css/timeline.css": ".tl-timeline { color: black; }"} {
+        err := os.MkdirAll(filepath.Dir(filepath.Join(libDir, path)), 0700)
+        if err == nil {
+            err = os.WriteFile(filepath.Join(libDir, path), []byte(contents), 0600)
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+    config := sync.TimelineConfig{
+        OutputFile: filepath.Join(dir, "timeline.json"),
+        HTMLFile: filepath.Join(dir, "timeline.html"),
+        TimelineJSDir: libDir,
+        Title: "My Year",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    viewer, err := os.ReadFile(config.HTMLFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, snippet := range []string{"<script>var TL = {};</script>", "<style>.tl-timeline { color: black; }</style>"} {
+        if !strings.Contains(string(viewer), snippet) {
+            t.Errorf("expected html viewer to contain %q", snippet)
+        }
+    }
+    if strings.Contains(string(viewer), "cdn.knightlab.com") {
+        t.Errorf("expected html viewer not to load anything from the cdn")
+    }
+}
+
+func TestTimelineExecReturnsErrWhenFailingToWriteTheTimeline(t *testing.T) {
+    config := sync.TimelineConfig{
+        OutputFile: filepath.Join(t.TempDir(), "missing", "timeline.json"),
+        Title: "My Year",
+        Cmd: mockCommand{outputString: siteJrnlOutput},
+    }
+    err := config.Exec(context.Background(), []string{})
+    if !errors.Is(err, sync.ErrFailedToWriteTimeline) {
+        t.Errorf("Expected error to be of type ErrFailedToWriteTimeline, got %+v", err)
+    }
+}